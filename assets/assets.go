@@ -0,0 +1,10 @@
+// Package assets embeds the default game assets into the binary.
+package assets
+
+import "embed"
+
+// Default assets, laid out as in this directory. Load them through an
+// asset.Manager so that they can be overridden from disk.
+//
+//go:embed blocks.json textures shaders fonts
+var FS embed.FS
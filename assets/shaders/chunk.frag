@@ -0,0 +1,78 @@
+#version 410 core
+
+#define SHADOW_CASCADES 3
+// brightness of emissive blocks over fully lit ones, above 1 so they bloom
+#define EMISSIVE_INTENSITY 4.0
+
+uniform sampler2DArray tTexture;
+uniform float uSkyLight; // sky brightness for the time of day
+uniform vec3 uFogColor;
+uniform float uFogDensity;
+
+uniform sampler2DArrayShadow tShadowMap;
+uniform mat4 uShadowMatrices[SHADOW_CASCADES];
+uniform float uCascadeSplits[SHADOW_CASCADES]; // far depth of each cascade
+uniform float uShadowTexelSizes[SHADOW_CASCADES]; // in world units
+uniform float uShadowStrength; // 0 disables shadows
+
+in vec3 fragTexCoord;
+in vec3 fragLight;
+in float fragEmission;
+in float fragDistance;
+in float fragDepth;
+in vec3 fragWorldPosition;
+
+out vec4 FragColor;
+
+// Maps a light level to a brightness, each level being ~80% as bright as the
+// one above, with a little ambient light so unlit caves aren't pitch black.
+float brightness(float level) {
+	return mix(0.04, 1.0, pow(0.8, (1.0 - level) * 15.0));
+}
+
+// Fraction of the fragment lit by the shadow casting light, from 0 in full
+// shadow to 1, filtered over 3x3 hardware 2x2 PCF taps.
+float shadow(vec3 normal) {
+	if (fragDepth > uCascadeSplits[SHADOW_CASCADES - 1]) {
+		return 1.0;
+	}
+	int cascade = 0;
+	while (fragDepth > uCascadeSplits[cascade]) {
+		cascade++;
+	}
+
+	// pushing the sample point off the surface avoids shadow acne
+	vec3 position = fragWorldPosition + normal * uShadowTexelSizes[cascade] * 1.5;
+	vec4 light = uShadowMatrices[cascade] * vec4(position, 1.0);
+	vec3 coord = light.xyz / light.w * 0.5 + 0.5;
+
+	vec2 texel = 1.0 / vec2(textureSize(tShadowMap, 0).xy);
+	float lit = 0.0;
+	for (int x = -1; x <= 1; x++) {
+		for (int y = -1; y <= 1; y++) {
+			vec2 offset = vec2(x, y) * texel;
+			lit += texture(tShadowMap, vec4(coord.xy + offset, cascade, coord.z));
+		}
+	}
+	return lit / 9.0;
+}
+
+void main() {
+	// faces are flat, so the normal follows from the position derivatives
+	vec3 normal = normalize(cross(dFdx(fragWorldPosition), dFdy(fragWorldPosition)));
+
+	float sky = fragLight.x * uSkyLight;
+	if (uShadowStrength > 0.0) {
+		sky *= 1.0 - uShadowStrength * (1.0 - shadow(normal));
+	}
+
+	float ao = mix(0.45, 1.0, fragLight.z);
+	float light = brightness(max(sky, fragLight.y)) * ao;
+	vec4 color = texture(tTexture, fragTexCoord);
+	vec3 lit = color.rgb * (light + fragEmission * EMISSIVE_INTENSITY);
+
+	// squared exponential fog, blending into the sky at the horizon; the
+	// sky colours are given in sRGB, the scene is drawn in linear space
+	float fog = 1.0 - exp(-pow(fragDistance * uFogDensity, 2.0));
+	FragColor = vec4(mix(lit, pow(uFogColor, vec3(2.2)), fog), color.a);
+}
@@ -0,0 +1,34 @@
+#version 410 core
+
+layout (std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+	mat4 uOrtho;
+};
+
+uniform mat4 uModel;
+
+layout (location = 0) in vec3 aPosition;
+layout (location = 1) in vec3 aTex; // st + texture array layer
+layout (location = 2) in vec3 aLight; // sky light, block light, ambient occlusion (0 to 1)
+layout (location = 3) in float aEmission; // light emitted by the block (0 to 1)
+
+out vec3 fragTexCoord;
+out vec3 fragLight;
+out float fragEmission;
+out float fragDistance; // distance to the camera, for fog
+out float fragDepth; // depth in view space, to pick the shadow cascade
+out vec3 fragWorldPosition;
+
+void main() {
+	vec4 worldPosition = uModel * vec4(aPosition, 1.0);
+	vec4 viewPosition = uView * worldPosition;
+	gl_Position = uProjection * viewPosition;
+	fragDistance = length(viewPosition.xyz);
+	fragDepth = -viewPosition.z;
+	fragWorldPosition = worldPosition.xyz;
+	fragTexCoord = aTex;
+	fragLight = aLight;
+	fragEmission = aEmission;
+}
+
@@ -0,0 +1,15 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform sampler2D tBloom;
+uniform float uIntensity;
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+void main() {
+	vec3 color = texture(tInput, vTexCoord).rgb;
+	color += texture(tBloom, vTexCoord).rgb * uIntensity;
+	FragColor = vec4(color, 1.0);
+}
@@ -0,0 +1,16 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform float uThreshold;
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+// Keeps the parts of the scene brighter than the threshold, which only
+// emissive blocks reach.
+void main() {
+	vec3 color = texture(tInput, vTexCoord).rgb;
+	float brightness = max(color.r, max(color.g, color.b));
+	FragColor = vec4(color * smoothstep(uThreshold, uThreshold + 0.5, brightness), 1.0);
+}
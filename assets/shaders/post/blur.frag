@@ -0,0 +1,19 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform vec2 uDirection; // one texel along the blur axis
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+// One axis of a 9 tap gaussian blur, using linear filtering to sample two
+// texels at once.
+void main() {
+	vec3 color = texture(tInput, vTexCoord).rgb * 0.2270270270;
+	color += texture(tInput, vTexCoord + uDirection * 1.3846153846).rgb * 0.3162162162;
+	color += texture(tInput, vTexCoord - uDirection * 1.3846153846).rgb * 0.3162162162;
+	color += texture(tInput, vTexCoord + uDirection * 3.2307692308).rgb * 0.0702702703;
+	color += texture(tInput, vTexCoord - uDirection * 3.2307692308).rgb * 0.0702702703;
+	FragColor = vec4(color, 1.0);
+}
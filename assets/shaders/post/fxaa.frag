@@ -0,0 +1,55 @@
+#version 410 core
+
+// Fast approximate anti-aliasing, after Timothy Lottes' FXAA. Runs on the
+// final, gamma corrected image.
+
+#define FXAA_SPAN_MAX 8.0
+#define FXAA_REDUCE_MUL (1.0 / 8.0)
+#define FXAA_REDUCE_MIN (1.0 / 128.0)
+
+uniform sampler2D tInput;
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+void main() {
+	vec2 texel = 1.0 / vec2(textureSize(tInput, 0));
+	vec3 luma = vec3(0.299, 0.587, 0.114);
+
+	float lumaNW = dot(texture(tInput, vTexCoord + vec2(-1.0, -1.0) * texel).rgb, luma);
+	float lumaNE = dot(texture(tInput, vTexCoord + vec2(1.0, -1.0) * texel).rgb, luma);
+	float lumaSW = dot(texture(tInput, vTexCoord + vec2(-1.0, 1.0) * texel).rgb, luma);
+	float lumaSE = dot(texture(tInput, vTexCoord + vec2(1.0, 1.0) * texel).rgb, luma);
+	vec3 colorM = texture(tInput, vTexCoord).rgb;
+	float lumaM = dot(colorM, luma);
+
+	float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+	float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+	// blur along the edge, perpendicular to the luma gradient
+	vec2 dir = vec2(
+		-((lumaNW + lumaNE) - (lumaSW + lumaSE)),
+		(lumaNW + lumaSW) - (lumaNE + lumaSE)
+	);
+	float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * FXAA_REDUCE_MUL, FXAA_REDUCE_MIN);
+	float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+	dir = clamp(dir * rcpDirMin, vec2(-FXAA_SPAN_MAX), vec2(FXAA_SPAN_MAX)) * texel;
+
+	vec3 rgbA = 0.5 * (
+		texture(tInput, vTexCoord + dir * (1.0 / 3.0 - 0.5)).rgb +
+		texture(tInput, vTexCoord + dir * (2.0 / 3.0 - 0.5)).rgb
+	);
+	vec3 rgbB = rgbA * 0.5 + 0.25 * (
+		texture(tInput, vTexCoord + dir * -0.5).rgb +
+		texture(tInput, vTexCoord + dir * 0.5).rgb
+	);
+
+	// the wider sample strayed off the edge
+	float lumaB = dot(rgbB, luma);
+	if (lumaB < lumaMin || lumaB > lumaMax) {
+		FragColor = vec4(rgbA, 1.0);
+	} else {
+		FragColor = vec4(rgbB, 1.0);
+	}
+}
@@ -0,0 +1,13 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform float uGamma;
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+void main() {
+	vec3 color = texture(tInput, vTexCoord).rgb;
+	FragColor = vec4(pow(max(color, 0.0), vec3(1.0 / uGamma)), 1.0);
+}
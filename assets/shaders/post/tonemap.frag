@@ -0,0 +1,18 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform float uExposure;
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+// Narkowicz's fit of the ACES filmic curve.
+vec3 aces(vec3 x) {
+	return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main() {
+	vec3 color = texture(tInput, vTexCoord).rgb;
+	FragColor = vec4(aces(color * uExposure), 1.0);
+}
@@ -0,0 +1,19 @@
+#version 410 core
+
+uniform sampler2D tInput;
+uniform vec3 uTint;
+uniform float uTime; // seconds
+
+in vec2 vTexCoord;
+
+out vec4 FragColor;
+
+void main() {
+	// slow ripple of the view
+	vec2 offset = vec2(sin(vTexCoord.y * 20.0 + uTime * 2.0), cos(vTexCoord.x * 20.0 + uTime * 1.7)) * 0.002;
+	vec3 color = texture(tInput, vTexCoord + offset).rgb;
+
+	// light is absorbed, leaving the colour of the water
+	float luma = dot(color, vec3(0.299, 0.587, 0.114));
+	FragColor = vec4(mix(color, uTint * luma * 1.5, 0.6), 1.0);
+}
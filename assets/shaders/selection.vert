@@ -1,8 +1,12 @@
 #version 410 core
 
+layout (std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+	mat4 uOrtho;
+};
+
 uniform mat4 uModel;
-uniform mat4 uView;
-uniform mat4 uProjection;
 
 layout (location = 0) in uvec3 aPosition;
 layout (location = 1) in uint aFaceIndex;
@@ -0,0 +1,5 @@
+#version 410 core
+
+// depth only
+void main() {
+}
@@ -0,0 +1,10 @@
+#version 410 core
+
+uniform mat4 uLightMatrix;
+uniform mat4 uModel;
+
+layout (location = 0) in vec3 aPosition;
+
+void main() {
+	gl_Position = uLightMatrix * uModel * vec4(aPosition, 1.0);
+}
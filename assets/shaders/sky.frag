@@ -0,0 +1,65 @@
+#version 410 core
+
+uniform vec3 uZenith;
+uniform vec3 uHorizon;
+uniform vec3 uSunDirection;
+uniform vec3 uMoonDirection;
+uniform float uSunSize; // angular radius, in radians
+uniform float uMoonSize;
+uniform float uStars; // star visibility, 0 hides them
+uniform mat3 uStarRotation;
+
+in vec3 vDirection;
+
+out vec4 FragColor;
+
+float hash(vec3 p) {
+	p = fract(p * 0.3183099 + 0.1);
+	p *= 17.0;
+	return fract(p.x * p.y * p.z * (p.x + p.y + p.z));
+}
+
+// Brightness of a disc of the given angular radius around dir, with a
+// slightly soft edge.
+float disc(vec3 dir, vec3 center, float size) {
+	float angle = acos(clamp(dot(dir, center), -1.0, 1.0));
+	return 1.0 - smoothstep(size * 0.85, size, angle);
+}
+
+float stars(vec3 dir) {
+	// one candidate star per cell of a grid wrapped around the sky
+	vec3 p = transpose(uStarRotation) * dir * 180.0;
+	vec3 cell = floor(p);
+	float h = hash(cell);
+	if (h < 0.985) {
+		return 0.0;
+	}
+
+	// the star is a small point at a random position inside the cell
+	vec3 center = cell + vec3(hash(cell + 1.7), hash(cell + 4.3), hash(cell + 9.1));
+	float d = length(p - center);
+	return (1.0 - smoothstep(0.0, 0.35, d)) * (h - 0.985) / 0.015;
+}
+
+void main() {
+	vec3 dir = normalize(vDirection);
+
+	// horizon colour below the horizon, blending into the zenith colour above
+	float height = max(dir.y, 0.0);
+	vec3 color = mix(uHorizon, uZenith, pow(height, 0.5));
+
+	// sun, moon and stars set behind the horizon
+	float above = smoothstep(-0.02, 0.02, dir.y);
+
+	color += vec3(0.9, 0.95, 1.0) * stars(dir) * uStars * above * height;
+
+	// soft glow around the sun
+	float glow = pow(max(dot(dir, uSunDirection), 0.0), 8.0);
+	color += vec3(1.0, 0.8, 0.5) * glow * 0.25 * smoothstep(-0.3, 0.1, uSunDirection.y);
+
+	color = mix(color, vec3(1.0, 0.95, 0.8), disc(dir, uSunDirection, uSunSize) * above);
+	color = mix(color, vec3(0.85, 0.88, 0.95), disc(dir, uMoonDirection, uMoonSize) * above);
+
+	// the colours are given in sRGB, the scene is drawn in linear space
+	FragColor = vec4(pow(color, vec3(2.2)), 1.0);
+}
@@ -0,0 +1,20 @@
+#version 410 core
+
+layout (std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+	mat4 uOrtho;
+};
+
+out vec3 vDirection;
+
+void main() {
+	// fullscreen triangle
+	vec2 pos = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2) * 2.0 - 1.0;
+	gl_Position = vec4(pos, 1.0, 1.0);
+
+	// world space view direction, ignoring the camera position
+	vec4 world = inverse(uProjection * mat4(mat3(uView))) * vec4(pos, 1.0, 1.0);
+	vDirection = world.xyz / world.w;
+}
+
@@ -0,0 +1,16 @@
+#version 410 core
+
+uniform sampler2D tFont;
+
+in vec2 fragTexCoord;
+in vec4 fragColor;
+
+out vec4 FragColor;
+
+void main() {
+	float coverage = texture(tFont, fragTexCoord).a;
+	if (coverage == 0.0) {
+		discard;
+	}
+	FragColor = vec4(fragColor.rgb, fragColor.a * coverage);
+}
@@ -0,0 +1,22 @@
+#version 410 core
+
+layout (std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+	mat4 uOrtho;
+};
+
+uniform mat4 uModel;
+
+layout (location = 0) in vec2 aPosition;
+layout (location = 1) in vec2 aTexCoord;
+layout (location = 2) in vec4 aColor;
+
+out vec2 fragTexCoord;
+out vec4 fragColor;
+
+void main() {
+	gl_Position = uOrtho * uModel * vec4(aPosition, 0.0, 1.0);
+	fragTexCoord = aTexCoord;
+	fragColor = aColor;
+}
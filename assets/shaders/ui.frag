@@ -1,10 +1,10 @@
 #version 410 core
 
-uniform vec3 uColor;
+uniform vec4 uColor;
 
 out vec4 FragColor;
 
 void main() {
-	FragColor = vec4(uColor, 1.0);
+	FragColor = uColor;
 }
 
@@ -0,0 +1,16 @@
+#version 410 core
+
+layout (std140) uniform Camera {
+	mat4 uProjection;
+	mat4 uView;
+	mat4 uOrtho;
+};
+
+uniform mat4 uModel;
+
+layout (location = 0) in vec2 aPosition;
+
+void main() {
+	gl_Position = uOrtho * uModel * vec4(aPosition, 0.0, 1.0);
+}
+
@@ -0,0 +1,152 @@
+// Command atlaspack packs every PNG in a directory into a single texture
+// atlas and writes a JSON sidecar describing where each texture ended up. The
+// game itself loads block textures into a texture array, so the atlas is only
+// meant for external tools.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hexagon-0/voxel-game/internal/common/pngfile"
+)
+
+// Rectangle of a texture in the atlas, in pixels with the origin at the
+// top-left corner of the atlas image.
+type atlasRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// The JSON sidecar written next to the atlas image.
+type atlasInfo struct {
+	Width    int                  `json:"width"`
+	Height   int                  `json:"height"`
+	Padding  int                  `json:"padding"`
+	Textures map[string]atlasRect `json:"textures"`
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "atlaspack:", err)
+	os.Exit(1)
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+// Copies src into dst at rect and repeats its border pixels over the padding
+// around it, so that texture filtering and lower mip levels near the edges
+// only ever sample the texture itself.
+func blitPadded(dst *image.RGBA, src *image.RGBA, rect image.Rectangle, padding int) {
+	size := src.Rect.Size()
+	for y := -padding; y < size.Y+padding; y++ {
+		for x := -padding; x < size.X+padding; x++ {
+			sx := clamp(x, 0, size.X-1)
+			sy := clamp(y, 0, size.Y-1)
+			dst.SetRGBA(rect.Min.X+x, rect.Min.Y+y, src.RGBAAt(sx, sy))
+		}
+	}
+}
+
+func main() {
+	dir := flag.String("dir", "assets/textures", "directory containing the PNG files to pack")
+	out := flag.String("out", "block_atlas.png", "atlas image to write")
+	sidecar := flag.String("json", "", "JSON sidecar to write (defaults to the atlas path with a .json extension)")
+	padding := flag.Int("padding", 2, "pixels of repeated border around each texture, for filtering and mipmaps")
+	flag.Parse()
+
+	if *sidecar == "" {
+		*sidecar = strings.TrimSuffix(*out, filepath.Ext(*out)) + ".json"
+	}
+	if *padding < 0 {
+		fail(fmt.Errorf("padding must not be negative"))
+	}
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.png"))
+	if err != nil {
+		fail(err)
+	}
+	sort.Strings(paths)
+
+	outAbs, err := filepath.Abs(*out)
+	if err != nil {
+		fail(err)
+	}
+
+	items := make([]packItem, 0, len(paths))
+	images := make([]*image.RGBA, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			fail(err)
+		}
+		if abs == outAbs {
+			continue // don't pack the previous atlas into the new one
+		}
+
+		img, err := pngfile.Load(path)
+		if err != nil {
+			fail(err)
+		}
+
+		name := strings.TrimSuffix(filepath.Base(path), ".png")
+		items = append(items, packItem{name, img.Rect.Size()})
+		images = append(images, img)
+	}
+
+	if len(items) == 0 {
+		fail(fmt.Errorf("no PNG files found in %s", *dir))
+	}
+
+	positions, size := packSquare(items, *padding)
+
+	atlasImage := image.NewRGBA(image.Rect(0, 0, size, size))
+	atlas := atlasInfo{
+		Width:    size,
+		Height:   size,
+		Padding:  *padding,
+		Textures: make(map[string]atlasRect),
+	}
+	for i, item := range items {
+		origin := positions[i].Add(image.Point{*padding, *padding})
+		rect := image.Rectangle{origin, origin.Add(item.size)}
+		blitPadded(atlasImage, images[i], rect, *padding)
+		atlas.Textures[item.name] = atlasRect{
+			X: rect.Min.X,
+			Y: rect.Min.Y,
+			W: item.size.X,
+			H: item.size.Y,
+		}
+	}
+
+	err = pngfile.Save(*out, atlasImage)
+	if err != nil {
+		fail(err)
+	}
+
+	data, err := json.MarshalIndent(atlas, "", "\t")
+	if err != nil {
+		fail(err)
+	}
+	err = os.WriteFile(*sidecar, append(data, '\n'), 0644)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("packed %d textures into %s (%dx%d), rects in %s\n", len(items), *out, size, size, *sidecar)
+}
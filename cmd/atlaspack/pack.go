@@ -0,0 +1,70 @@
+package main
+
+import (
+	"image"
+	"sort"
+)
+
+type packItem struct {
+	name string
+	size image.Point
+}
+
+// Places items in rows ("shelves") from the tallest to the shortest, starting
+// a new row whenever the current one is full. Each item takes its size plus
+// padding on every side. Returns the top-left corner of each item's padded
+// cell, in the order of the given items, and the height used.
+func shelfPack(items []packItem, width, padding int) ([]image.Point, int, bool) {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return items[order[a]].size.Y > items[order[b]].size.Y
+	})
+
+	positions := make([]image.Point, len(items))
+	x, y, shelfHeight := 0, 0, 0
+	for _, i := range order {
+		w := items[i].size.X + 2*padding
+		h := items[i].size.Y + 2*padding
+		if w > width {
+			return nil, 0, false
+		}
+
+		if x+w > width {
+			x = 0
+			y += shelfHeight
+			shelfHeight = 0
+		}
+
+		positions[i] = image.Point{x, y}
+		x += w
+		if h > shelfHeight {
+			shelfHeight = h
+		}
+	}
+
+	return positions, y + shelfHeight, true
+}
+
+// Finds the smallest square power of two atlas that fits every item.
+func packSquare(items []packItem, padding int) ([]image.Point, int) {
+	area := 0
+	for _, item := range items {
+		area += (item.size.X + 2*padding) * (item.size.Y + 2*padding)
+	}
+
+	size := 1
+	for size*size < area {
+		size *= 2
+	}
+
+	for {
+		positions, height, ok := shelfPack(items, size, padding)
+		if ok && height <= size {
+			return positions, size
+		}
+		size *= 2
+	}
+}
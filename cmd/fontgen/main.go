@@ -0,0 +1,108 @@
+// Command fontgen renders the printable ASCII characters of a font face into
+// a bitmap font atlas and writes a JSON sidecar with the metrics of each
+// glyph, in the format read by render.ParseFontInfo.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hexagon-0/voxel-game/internal/client/render"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyphs per row of the atlas
+const COLUMNS = 16
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "fontgen:", err)
+	os.Exit(1)
+}
+
+func main() {
+	out := flag.String("out", "assets/fonts/default.png", "atlas image to write")
+	sidecar := flag.String("json", "", "JSON sidecar to write (defaults to the atlas path with a .json extension)")
+	padding := flag.Int("padding", 1, "empty pixels between glyphs")
+	flag.Parse()
+
+	if *sidecar == "" {
+		*sidecar = strings.TrimSuffix(*out, filepath.Ext(*out)) + ".json"
+	}
+	if *padding < 0 {
+		fail(fmt.Errorf("padding must not be negative"))
+	}
+
+	// the X11 misc-fixed 7x13 face, public domain
+	face := basicfont.Face7x13
+	ascent := face.Ascent
+
+	runes := []rune{}
+	for r := rune(' '); r <= '~'; r++ {
+		runes = append(runes, r)
+	}
+
+	cellW := face.Advance + *padding
+	cellH := face.Height + *padding
+	rows := (len(runes) + COLUMNS - 1) / COLUMNS
+
+	atlasImage := image.NewRGBA(image.Rect(0, 0, COLUMNS*cellW, rows*cellH))
+	info := render.FontInfo{
+		Width:      atlasImage.Rect.Dx(),
+		Height:     atlasImage.Rect.Dy(),
+		LineHeight: face.Height,
+		Glyphs:     make(map[rune]render.Glyph),
+	}
+
+	white := image.NewUniform(color.White)
+	for i, r := range runes {
+		cell := image.Pt(i%COLUMNS*cellW, i/COLUMNS*cellH)
+
+		// the dot sits on the baseline, ascent pixels below the top of the line
+		dr, mask, maskp, advance, ok := face.Glyph(fixed.P(0, ascent), r)
+		if !ok {
+			fail(fmt.Errorf("the face has no glyph for %q", r))
+		}
+
+		dst := dr.Add(cell)
+		draw.DrawMask(atlasImage, dst, white, image.Point{}, mask, maskp, draw.Over)
+		info.Glyphs[r] = render.Glyph{
+			X:       dst.Min.X,
+			Y:       dst.Min.Y,
+			W:       dr.Dx(),
+			H:       dr.Dy(),
+			XOffset: dr.Min.X,
+			YOffset: dr.Min.Y,
+			Advance: advance.Round(),
+		}
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fail(err)
+	}
+	err = png.Encode(file, atlasImage)
+	file.Close()
+	if err != nil {
+		fail(err)
+	}
+
+	data, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		fail(err)
+	}
+	err = os.WriteFile(*sidecar, append(data, '\n'), 0644)
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("rendered %d glyphs into %s (%dx%d), metrics in %s\n", len(runes), *out, info.Width, info.Height, *sidecar)
+}
@@ -0,0 +1,145 @@
+// Command snapshot renders a generated world from a fixed camera pose without
+// a visible window and writes the frame to a PNG. Given a golden image, it
+// compares the frame against it instead and fails if they differ, for
+// catching unintended meshing and shader changes in CI:
+//
+//	xvfb-run go run ./cmd/snapshot -golden cmd/snapshot/testdata/noon.png
+//
+// Use -out to (re)generate golden images. The test of this package runs the
+// same comparison for the default snapshot when a display and the golden
+// image are available:
+//
+//	xvfb-run go test ./cmd/snapshot
+//	xvfb-run go test ./cmd/snapshot -update
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/app"
+	"github.com/hexagon-0/voxel-game/internal/common/pngfile"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/space"
+)
+
+// Defaults of the comparison against golden images.
+const (
+	// largest channel difference ignored
+	DIFF_THRESHOLD = 8
+	// fraction of the pixels allowed to differ
+	MAX_DIFF = 0.001
+)
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "snapshot:", err)
+	os.Exit(1)
+}
+
+// Counts the pixels of a and b whose channels differ by more than
+// threshold. Software and hardware rasterisers don't agree to the bit, so
+// small differences are expected.
+func diffImages(a, b *image.RGBA, threshold int) (int, error) {
+	if a.Rect.Size() != b.Rect.Size() {
+		return 0, fmt.Errorf("image sizes differ: %v and %v", a.Rect.Size(), b.Rect.Size())
+	}
+
+	differing := 0
+	for i := 0; i < len(a.Pix); i += 4 {
+		for c := 0; c < 4; c++ {
+			d := int(a.Pix[i+c]) - int(b.Pix[i+c])
+			if d > threshold || -d > threshold {
+				differing++
+				break
+			}
+		}
+	}
+	return differing, nil
+}
+
+// Compares img against the golden image at path, failing if more than
+// maxDiff of its pixels differ by more than threshold. Returns how many
+// pixels differ.
+func compareGolden(img *image.RGBA, path string, threshold int, maxDiff float64) (int, error) {
+	expected, err := pngfile.Load(path)
+	if err != nil {
+		return 0, err
+	}
+
+	differing, err := diffImages(img, expected, threshold)
+	if err != nil {
+		return 0, err
+	}
+
+	size := img.Rect.Size()
+	fraction := float64(differing) / float64(size.X*size.Y)
+	if fraction > maxDiff {
+		return differing, fmt.Errorf("%d pixels (%.2f%%) differ from %s", differing, fraction*100, path)
+	}
+	return differing, nil
+}
+
+func main() {
+	snapshot := app.DefaultSnapshot()
+	eye := snapshot.Camera.Origin
+	target := eye.Sub(snapshot.Camera.Basis.Z())
+
+	out := flag.String("out", "", "PNG file to write the frame to")
+	golden := flag.String("golden", "", "golden image to compare the frame against")
+	threshold := flag.Int("threshold", DIFF_THRESHOLD, "largest channel difference ignored when comparing")
+	maxDiff := flag.Float64("max-diff", MAX_DIFF, "fraction of pixels allowed to differ from the golden image")
+	flag.IntVar(&snapshot.Width, "width", snapshot.Width, "width of the frame")
+	flag.IntVar(&snapshot.Height, "height", snapshot.Height, "height of the frame")
+	flag.IntVar(&snapshot.WorldSize, "size", snapshot.WorldSize, "width and depth of the world, in chunks")
+	flag.IntVar(&snapshot.WorldHeight, "world-height", snapshot.WorldHeight, "height of the world, in chunks")
+	flag.Int64Var(&snapshot.Time, "time", snapshot.Time, "time of day, in ticks since midnight")
+	eyeFlag := flag.String("eye", space.FormatVec3(eye), "camera position")
+	targetFlag := flag.String("target", space.FormatVec3(target), "point the camera looks at")
+	fovy := flag.Float64("fov", float64(mgl32.RadToDeg(snapshot.Fovy)), "vertical field of view, in degrees")
+	flag.Parse()
+
+	if *out == "" && *golden == "" {
+		fail(fmt.Errorf("nothing to do, give -out and/or -golden"))
+	}
+	if snapshot.Width <= 0 || snapshot.Height <= 0 {
+		fail(fmt.Errorf("invalid frame size %dx%d", snapshot.Width, snapshot.Height))
+	}
+
+	eye, err := space.ParseVec3(*eyeFlag)
+	if err != nil {
+		fail(err)
+	}
+	target, err = space.ParseVec3(*targetFlag)
+	if err != nil {
+		fail(err)
+	}
+	snapshot.Camera = space.LookAt(eye, target, scene.WorldUp)
+	snapshot.Fovy = float32(*fovy * math.Pi / 180)
+
+	// GL calls must come from the thread that created the context
+	runtime.LockOSThread()
+	img, err := app.RenderSnapshot(snapshot)
+	if err != nil {
+		fail(err)
+	}
+
+	if *out != "" {
+		err = pngfile.Save(*out, img)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	if *golden != "" {
+		differing, err := compareGolden(img, *golden, *threshold, *maxDiff)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("matches %s (%d pixels differ)\n", *golden, differing)
+	}
+}
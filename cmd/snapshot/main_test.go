@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hexagon-0/voxel-game/internal/client/app"
+	"github.com/hexagon-0/voxel-game/internal/common/pngfile"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images instead of comparing against them")
+
+// Golden image of app.DefaultSnapshot.
+var NOON_GOLDEN = filepath.Join("testdata", "noon.png")
+
+func TestNoonSnapshot(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		t.Skip("no display to create a GL context on, run under xvfb-run")
+	}
+
+	// GL calls must come from the thread that created the context
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	img, err := app.RenderSnapshot(app.DefaultSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		err = pngfile.Save(NOON_GOLDEN, img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if _, err := os.Stat(NOON_GOLDEN); errors.Is(err, fs.ErrNotExist) {
+		t.Skipf("no golden image at %s, generate it with `xvfb-run go test ./cmd/snapshot -update`", NOON_GOLDEN)
+	}
+	_, err = compareGolden(img, NOON_GOLDEN, DIFF_THRESHOLD, MAX_DIFF)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	b.Set(0, 0, color.RGBA{DIFF_THRESHOLD, 0, 0, 0})
+	b.Set(1, 0, color.RGBA{0, DIFF_THRESHOLD + 1, 0, 0})
+	b.Set(2, 0, color.RGBA{0, 0, 0, 255})
+
+	differing, err := diffImages(a, b, DIFF_THRESHOLD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if differing != 2 {
+		t.Fatalf("%d pixels differ, want 2 as differences up to the threshold are ignored", differing)
+	}
+
+	_, err = diffImages(a, image.NewRGBA(image.Rect(0, 0, 4, 3)), DIFF_THRESHOLD)
+	if err == nil {
+		t.Fatal("images of different sizes compared without error")
+	}
+}
@@ -0,0 +1,109 @@
+// Command thumbnail renders a generated world to a PNG on the CPU, with the
+// raster package, so it works without a GPU, a display or an OpenGL context.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/assets"
+	"github.com/hexagon-0/voxel-game/internal/common/pngfile"
+	"github.com/hexagon-0/voxel-game/internal/common/raster"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/space"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "thumbnail:", err)
+	os.Exit(1)
+}
+
+func main() {
+	out := flag.String("out", "thumbnail.png", "PNG file to write")
+	width := flag.Int("width", 320, "width of the image")
+	height := flag.Int("height", 240, "height of the image")
+	size := flag.Int("size", 4, "width and depth of the world, in chunks")
+	worldHeight := flag.Int("world-height", 2, "height of the world, in chunks")
+	ticks := flag.Int64("time", world.TIME_NOON, "time of day, in ticks since midnight")
+	eyeFlag := flag.String("eye", "", "camera position (defaults to above a corner of the world)")
+	targetFlag := flag.String("target", "", "point the camera looks at (defaults to the middle of the world)")
+	fovy := flag.Float64("fov", 60, "vertical field of view, in degrees")
+	flag.Parse()
+
+	if *width <= 0 || *height <= 0 {
+		fail(fmt.Errorf("invalid image size %dx%d", *width, *height))
+	}
+
+	extent := float32(*size * world.CHUNK_SIZE)
+	top := float32(*worldHeight * world.CHUNK_SIZE)
+	eye := mgl32.Vec3{0, top + 8, 0}
+	target := mgl32.Vec3{extent / 2, top / 2, extent / 2}
+	var err error
+	if *eyeFlag != "" {
+		eye, err = space.ParseVec3(*eyeFlag)
+		if err != nil {
+			fail(err)
+		}
+	}
+	if *targetFlag != "" {
+		target, err = space.ParseVec3(*targetFlag)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	blockDefs, err := assets.FS.ReadFile("blocks.json")
+	if err != nil {
+		fail(err)
+	}
+	registry, err := world.ParseBlockRegistry(blockDefs)
+	if err != nil {
+		fail(err)
+	}
+	textures, err := scene.LoadBlockImages(registry, func(name string) (*image.RGBA, error) {
+		return scene.LoadImage(assets.FS, "textures/"+name+".png")
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	w := world.World{Chunks: make(map[[3]int]*world.Chunk), Registry: registry, Time: *ticks}
+	for x := 0; x < *size; x++ {
+		for y := 0; y < *worldHeight; y++ {
+			for z := 0; z < *size; z++ {
+				err = w.LoadChunk(x, y, z)
+				if err != nil {
+					fail(err)
+				}
+			}
+		}
+	}
+	w.InitLighting()
+
+	sky := scene.NewSkyState(w.TimeOfDay())
+	horizon := sky.Horizon.Mul(255)
+
+	canvas := raster.NewTarget(*width, *height)
+	canvas.Clear(color.RGBA{uint8(horizon[0]), uint8(horizon[1]), uint8(horizon[2]), 255})
+
+	rasterizer := raster.NewRasterizer(canvas, raster.ChunkVertexLayout, textures.Images)
+	rasterizer.SkyLight = sky.SkyLight
+	rasterizer.SetCamera(space.LookAt(eye, target, scene.WorldUp), float32(*fovy*math.Pi/180), 0.1, 1000)
+
+	for key, chunk := range w.Chunks {
+		origin := [3]float32{float32(key[0]), float32(key[1]), float32(key[2])}
+		vertices, indices := scene.MeshChunk(chunk, origin, textures.Layers, scene.WorldLightSampler(&w, key))
+		rasterizer.DrawMesh(vertices, indices)
+	}
+
+	err = pngfile.Save(*out, canvas.Color)
+	if err != nil {
+		fail(err)
+	}
+}
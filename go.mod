@@ -6,6 +6,5 @@ require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20240506104042-037f3cc74f2a
 	github.com/go-gl/mathgl v1.0.0
+	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f
 )
-
-require golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f // indirect
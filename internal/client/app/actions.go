@@ -0,0 +1,55 @@
+package app
+
+import (
+	"github.com/hexagon-0/voxel-game/internal/client/game"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+)
+
+// actionInfo describes an action for the key bindings screen.
+type actionInfo struct {
+	action input.Action
+	label  string
+	// default binding
+	button input.Button
+	// default gamepad binding, none if its name is empty
+	gamepad input.Button
+}
+
+// Every action, in the order of the key bindings screen. Moving is done with
+// the left stick on a gamepad.
+var ACTIONS = []actionInfo{
+	{game.ACTION_MOVE_FORWARD, "Forward", input.Key("W"), input.Button{}},
+	{game.ACTION_MOVE_BACK, "Back", input.Key("S"), input.Button{}},
+	{game.ACTION_MOVE_LEFT, "Left", input.Key("A"), input.Button{}},
+	{game.ACTION_MOVE_RIGHT, "Right", input.Key("D"), input.Button{}},
+	{game.ACTION_JUMP, "Fly up", input.Key("Space"), input.Gamepad("a")},
+	{game.ACTION_SNEAK, "Fly down", input.Key("LeftShift"), input.Gamepad("b")},
+	{game.ACTION_SPRINT, "Sprint", input.Key("LeftControl"), input.Gamepad("ls")},
+	{game.ACTION_BREAK, "Break block", input.Mouse("left"), input.Gamepad(input.GAMEPAD_RIGHT_TRIGGER)},
+	{game.ACTION_PLACE, "Place block", input.Mouse("right"), input.Gamepad(input.GAMEPAD_LEFT_TRIGGER)},
+	{game.ACTION_NEXT_BLOCK, "Next block", input.Scroll(input.SCROLL_DOWN), input.Gamepad("rb")},
+	{game.ACTION_PREV_BLOCK, "Previous block", input.Scroll(input.SCROLL_UP), input.Gamepad("lb")},
+	{game.ACTION_PAUSE, "Pause", input.Key("Escape"), input.Gamepad("start")},
+	{game.ACTION_TOGGLE_DEBUG, "Debug overlay", input.Key("F3"), input.Gamepad("back")},
+	{game.ACTION_RELOAD_ASSETS, "Reload assets", input.Key("F5"), input.Button{}},
+}
+
+// Default button of every action, by action name as saved in the settings.
+func defaultBindings() map[string]string {
+	bindings := make(map[string]string, len(ACTIONS))
+	for _, info := range ACTIONS {
+		bindings[string(info.action)] = info.button.String()
+	}
+	return bindings
+}
+
+// Default gamepad button of every action that has one, by action name.
+func defaultGamepadBindings() map[string]string {
+	bindings := make(map[string]string, len(ACTIONS))
+	for _, info := range ACTIONS {
+		if info.gamepad.Name != "" {
+			bindings[string(info.action)] = info.gamepad.String()
+		}
+	}
+	return bindings
+}
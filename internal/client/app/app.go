@@ -2,45 +2,124 @@ package app
 
 import (
 	"fmt"
-	"image"
-	"image/draw"
-	_ "image/png"
+	"io/fs"
 	"math"
-	"os"
+	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/game"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
 	"github.com/hexagon-0/voxel-game/internal/client/render"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+	"github.com/hexagon-0/voxel-game/internal/common/command"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
 	"github.com/hexagon-0/voxel-game/internal/common/world"
 )
 
+// Defaults of the settings, see DefaultSettings.
 const (
 	WIDTH  = 1600
 	HEIGHT = 900
 
 	FPS = 60
 
-	LOOK_SENSITIVITY = 0.025
+	// how far the world is visible, in chunks, before it fades into the fog
+	VIEW_DISTANCE = 4
+)
+
+const (
+	// world ticks per second, whatever the frame rate
+	TICK_RATE = 60
+	// longest time a single frame moves the camera and the world by, so a
+	// stall doesn't throw the player across the map
+	MAX_FRAME_TIME = 0.25
+
+	// radians the camera turns per pixel the mouse moves, at sensitivity 1
+	LOOK_SENSITIVITY = 0.025 / 60
 
 	WORLD_HEIGHT = 2
 	WORLD_SIZE   = 4
+
+	// clip planes of the camera projection
+	NEAR_PLANE = 0.001
+	FAR_PLANE  = 1000.0
+
+	// assets in this directory, if it exists, override the embedded ones
+	ASSETS_DIR = "assets"
+
+	// font used by the interface, loaded from fonts/<name>.png and .json
+	FONT_NAME = "default"
 )
 
-func resizeCallback(window *glfw.Window, w, h int) {
-	gl.Viewport(0, 0, int32(w), int32(h))
+// Position and orientation of the player camera, angles in radians.
+type cameraState struct {
+	position mgl32.Vec3
+	yaw      float64
+	pitch    float64
+}
+
+// Where the camera starts in a world.
+var SPAWN_CAMERA = cameraState{
+	position: mgl32.Vec3{0.0, 0.0, -5.0},
+	yaw:      math.Pi / 2,
 }
 
 type App struct {
 	window        *glfw.Window
 	world         world.World
+	// directory the world being played is saved in
+	saveDir       string
+	// a world is being played, rather than browsing the menus
+	playing       bool
+	// the world is frozen behind the pause menu
+	paused        bool
+	camera        cameraState
+	settings      Settings
+	// the settings differ from the saved ones
+	settingsDirty bool
+	res           *resources
 	worldRenderer render.WorldRenderer
+	skyRenderer   render.SkyRenderer
+	selection     *render.SelectionPass
+	crosshair     *render.CrosshairPass
+	text          *render.TextPass
+	ui            *render.UiPass
+	menu          *ui.Root
+	renderer      *render.Renderer
 	raycast       world.VoxelRaycast
-	flag          bool
+	debug         debugOverlay
+	// window state the display settings were last applied with
+	display       displayState
+	source        *windowInput
+	gamepad       *input.GamepadSource
+	padMapping    GamepadMapping
+	// the gamepad was used last, rather than the keyboard and mouse
+	usingGamepad  bool
+	actions       *input.Map
+	// takes the next button pressed while a key binding is being changed
+	rebind        func(button input.Button)
+	// the input of the last update went to a key binding
+	latchInput    bool
+	// the block placed, kept across worlds
+	player        game.Player
+	commands      *command.Registry
+	console       <-chan string
 }
 
 func (self *App) Init() {
-	err := glfw.Init()
+	var err error
+	self.settings, err = LoadSettings(settingsPath())
+	if err != nil {
+		fmt.Println("failed to load settings:", err)
+	}
+	self.padMapping, err = LoadGamepadMapping(configPath(GAMEPAD_FILE))
+	if err != nil {
+		fmt.Println("failed to load gamepad mapping:", err)
+	}
+
+	err = glfw.Init()
 	if err != nil {
 		panic(err)
 	}
@@ -49,19 +128,20 @@ func (self *App) Init() {
 	glfw.WindowHint(glfw.ContextVersionMinor, 1)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+	displayHints()
 
-	self.window, err = glfw.CreateWindow(WIDTH, HEIGHT, "Hello OpenGL", nil, nil)
+	self.window, err = glfw.CreateWindow(self.settings.Video.Width, self.settings.Video.Height, "Hello OpenGL", nil, nil)
 	if err != nil {
 		panic(err)
 	}
 
-	videoMode := glfw.GetPrimaryMonitor().GetVideoMode()
-	self.window.SetPos((videoMode.Width-WIDTH)/2, (videoMode.Height-HEIGHT)/2)
-
 	self.window.MakeContextCurrent()
+	self.applyDisplay()
 
-	self.window.SetFramebufferSizeCallback(resizeCallback)
-	self.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	err = loadControllerDb()
+	if err != nil {
+		fmt.Println("failed to load gamepad database:", err)
+	}
 
 	err = gl.Init()
 	if err != nil {
@@ -77,34 +157,12 @@ func (self *App) Deinit() {
 	glfw.Terminate()
 }
 
-func LoadTexture(path string) (uint32, error) {
-	imgFile, err := os.Open(path)
+func LoadTexture(assets fs.FS, path string) (uint32, error) {
+	rgba, err := scene.LoadImage(assets, path)
 	if err != nil {
 		return 0, err
 	}
 
-	img, _, err := image.Decode(imgFile)
-	if err != nil {
-		return 0, err
-	}
-
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("Unsupported image stride")
-	}
-
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	// flip vertically
-	tmp := image.NewRGBA(rgba.Bounds())
-	height := rgba.Rect.Size().Y
-	s := rgba.Stride
-	for i := 0; i < height; i++ {
-		j := height - i
-		copy(tmp.Pix[(j-1)*s:j*s], rgba.Pix[i*s:(i+1)*s])
-	}
-	rgba = tmp
-
 	var texture uint32
 	gl.GenTextures(1, &texture)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
@@ -127,420 +185,370 @@ func LoadTexture(path string) (uint32, error) {
 	return texture, nil
 }
 
-func (self *App) Run() {
-	self.Init()
-	defer self.Deinit()
-
-	blockRepo := render.BlockRepo(make(map[world.BlockId]image.Rectangle))
-	blockAtlasTexture, err := LoadTexture("assets/textures/block_atlas.png")
-	if err != nil {
-		panic(err)
+// Makes res the current resources and keeps the renderers pointing at the
+// latest version of each shader.
+func (self *App) useResources(res *resources) {
+	self.res = res
+	self.world.Registry = res.blockRegistry
+	self.worldRenderer.Shader = res.chunkShader.Program
+	res.chunkShader.OnReload = func(program render.ShaderProgram) {
+		self.worldRenderer.Shader = program
 	}
-	_ = blockAtlasTexture
-	blockRepo[1] = image.Rectangle{image.Point{0, 0}, image.Point{16, 16}}
-	blockRepo[2] = image.Rectangle{image.Point{16, 0}, image.Point{32, 16}}
-	blockRepo[3] = image.Rectangle{image.Point{32, 0}, image.Point{48, 16}}
-	blockRepo[4] = image.Rectangle{image.Point{48, 0}, image.Point{64, 16}}
-
-	// commonDirtTexture, err := LoadTexture("assets/textures/common_dirt.png")
-	commonDirtTexture, err := LoadTexture("assets/textures/uv_test.png")
-	if err != nil {
-		panic(err)
+	self.skyRenderer.Shader = res.skyShader.Program
+	res.skyShader.OnReload = func(program render.ShaderProgram) {
+		self.skyRenderer.Shader = program
 	}
-	gl.BindTexture(gl.TEXTURE_2D, commonDirtTexture)
-	gl.GenerateMipmap(gl.TEXTURE_2D)
-
-	// err := self.worldRenderer.CompileShaders()
-	// if err != nil {
-	// 	panic(err)
-	// }
-
-	// Initialize world
-	self.world.Chunks = make(map[[3]int]*world.Chunk)
-	tcCoords := [3]int{0, 0, 0} // test chunk coordinates
-	self.world.LoadChunk(tcCoords[0], tcCoords[1], tcCoords[2])
-	// self.worldRenderer.BuildChunkMeshes(self.world)
-	chunkMesh := render.NewChunkMesh(world.CHUNK_SIZE, world.CHUNK_SIZE, world.CHUNK_SIZE)
-	err = render.BuildChunkMesh(&chunkMesh, tcCoords[0], tcCoords[1], tcCoords[2], &self.world, &blockRepo)
-	if err != nil {
-		panic(err)
+	self.worldRenderer.Shadows.Shader = res.shadowShader.Program
+	res.shadowShader.OnReload = func(program render.ShaderProgram) {
+		self.worldRenderer.Shadows.Shader = program
 	}
-
-	// test
-
-	// chunk shader
-	vs, err := render.NewShader(render.ChunkVsSource, gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
+	self.selection.Shader = res.selectShader.Program
+	res.selectShader.OnReload = func(program render.ShaderProgram) {
+		self.selection.Shader = program
 	}
-
-	fs, err := render.NewShader(render.ChunkFsSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		panic(err)
-	}
-
-	shader, err := render.NewShaderProgram(vs, fs)
-	if err != nil {
-		panic(err)
+	self.crosshair.Shader = res.uiShader.Program
+	self.ui.Shader = res.uiShader.Program
+	res.uiShader.OnReload = func(program render.ShaderProgram) {
+		self.crosshair.Shader = program
+		self.ui.Shader = program
 	}
-
-	// selection shader
-	vs, err = render.NewShader(render.SelectionVsSource, gl.VERTEX_SHADER)
-	if err != nil {
-		panic(err)
+	self.text.Shader = res.textShader.Program
+	self.ui.Text.Shader = res.textShader.Program
+	res.textShader.OnReload = func(program render.ShaderProgram) {
+		self.text.Shader = program
+		self.ui.Text.Shader = program
 	}
-
-	fs, err = render.NewShader(render.SelectionFsSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		panic(err)
+	self.text.Font = res.font
+	self.ui.Text.Font = res.font
+	for name, managed := range res.postShaders {
+		name := name
+		self.renderer.Post.Shaders[name] = managed.Program
+		managed.OnReload = func(program render.ShaderProgram) {
+			self.renderer.Post.Shaders[name] = program
+		}
 	}
+	self.worldRenderer.Texture = res.blockTextures.Texture
+}
 
-	selectShader, err := render.NewShaderProgram(vs, fs)
+// Reloads every asset from the resource packs and remeshes the world. If
+// anything fails to load, the current resources are kept.
+func (self *App) reloadResources() {
+	res, err := loadResources(true)
 	if err != nil {
-		panic(err)
-	}
-
-	vertices := []uint32{
-		// +X
-		1, 0, 1, 0,
-		1, 0, 0, 0,
-		1, 1, 1, 0,
-		1, 1, 0, 0,
-
-		// -X
-		0, 0, 0, 1,
-		0, 0, 1, 1,
-		0, 1, 0, 1,
-		0, 1, 1, 1,
-
-		// +Y
-		0, 1, 1, 2,
-		1, 1, 1, 2,
-		0, 1, 0, 2,
-		1, 1, 0, 2,
-
-		// -Y
-		0, 0, 0, 3,
-		1, 0, 0, 3,
-		0, 0, 1, 3,
-		1, 0, 1, 3,
-
-		// +Z
-		0, 0, 1, 4,
-		1, 0, 1, 4,
-		0, 1, 1, 4,
-		1, 1, 1, 4,
-
-		// -Z
-		1, 0, 0, 5,
-		0, 0, 0, 5,
-		1, 1, 0, 5,
-		0, 1, 0, 5,
+		fmt.Println("failed to reload resources:", err)
+		if res != nil {
+			res.Delete()
+		}
+		return
 	}
 
-	indices := []uint32{
-		// +X
-		0, 1, 2,
-		1, 3, 2,
-
-		// -X
-		4, 5, 6,
-		5, 7, 6,
-
-		// +Y
-		8, 9, 10,
-		9, 11, 10,
-
-		// -Y
-		12, 13, 14,
-		13, 15, 14,
+	self.res.Delete()
+	self.useResources(res)
 
-		// +Z
-		16, 17, 18,
-		17, 19, 18,
-
-		// -Z
-		20, 21, 22,
-		21, 23, 22,
+	// block definitions, and so light emission, may have changed
+	self.world.InitLighting()
+	err = self.worldRenderer.BuildChunkMeshes(&self.world, res.blockTextures.Layers)
+	if err != nil {
+		fmt.Println("failed to rebuild chunk meshes:", err)
 	}
 
-	var vbo, ebo, vao uint32
-	gl.GenBuffers(1, &vbo)
-	gl.GenBuffers(1, &ebo)
-	gl.GenVertexArrays(1, &vao)
-
-	gl.BindVertexArray(vao)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
-
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribIPointerWithOffset(0, 3, gl.UNSIGNED_INT, 4*4, 0)
-
-	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribIPointerWithOffset(1, 1, gl.UNSIGNED_INT, 4*4, 3*4)
-
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
-
-	gl.BindVertexArray(0)
+	fmt.Printf("reloaded resources (%d packs)\n", len(res.packs))
+}
 
-	// end: test
+func perspective(fovy, aspect float32) mgl32.Mat4 {
+	return mgl32.Perspective(fovy, aspect, NEAR_PLANE, FAR_PLANE)
+}
 
-	// UI
+// Creates the renderer with the passes drawing the world. The crosshair,
+// interface and text are created but left for the caller to add, so
+// snapshots don't show them.
+func (self *App) initRendering(width, height int32) error {
+	self.renderer = render.NewRenderer()
 
-	vs, err = render.NewShader(render.UiVsSource, gl.VERTEX_SHADER)
+	postChain, err := render.NewPostChain(width, height)
 	if err != nil {
-		panic(err)
+		self.renderer.Delete()
+		return err
 	}
+	self.renderer.Post = postChain
+
+	self.skyRenderer = render.NewSkyRenderer()
+	self.worldRenderer.Shadows = render.NewShadowMap(render.SHADOW_MAP_SIZE)
+	self.selection = render.NewSelectionPass()
+	self.crosshair = render.NewCrosshairPass(CROSSHAIR_SIZE)
+	self.text = render.NewTextPass()
+	self.ui = render.NewUiPass()
+
+	self.renderer.Add(&self.skyRenderer)
+	self.renderer.Add(&self.worldRenderer)
+	self.renderer.Add(self.selection)
+	return nil
+}
 
-	fs, err = render.NewShader(render.UiFsSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		panic(err)
-	}
+func (self *App) deleteRendering() {
+	self.ui.Delete()
+	self.text.Delete()
+	self.crosshair.Delete()
+	self.selection.Delete()
+	self.worldRenderer.Shadows.Delete()
+	self.renderer.Post.Delete()
+	self.renderer.Delete()
+}
 
-	uiShader, err := render.NewShaderProgram(vs, fs)
-	if err != nil {
-		panic(err)
+// Generates a world of size x height x size chunks and meshes it.
+func (self *App) loadWorld(size, height int) error {
+	self.world.Chunks = make(map[[3]int]*world.Chunk)
+	for x := 0; x < size; x++ {
+		for y := 0; y < height; y++ {
+			for z := 0; z < size; z++ {
+				err := self.world.LoadChunk(x, y, z)
+				if err != nil {
+					return err
+				}
+			}
+		}
 	}
+	self.world.InitLighting()
+	self.worldRenderer.FogDistance = float32(self.settings.Video.ViewDistance * world.CHUNK_SIZE)
+	return self.worldRenderer.BuildChunkMeshes(&self.world, self.res.blockTextures.Layers)
+}
 
-	orthoMatrix := mgl32.Ortho2D(0, WIDTH, 0, HEIGHT)
-	err = uiShader.SetUniformMatrix4fv("uProjection", orthoMatrix)
-	if err != nil {
-		panic(err)
-	}
-	// err = uiShader.SetUniformMatrix4fv("uView", mgl32.Ident4())
-	// if err != nil {
-	// 	panic(err)
-	// }
-	err = uiShader.SetUniformMatrix4fv("uModel", mgl32.Translate3D(float32(WIDTH)/2, float32(HEIGHT)/2, 0.0))
-	// err = uiShader.SetUniformMatrix4fv("uModel", mgl32.Ident4())
+func (self *App) Run() {
+	self.Init()
+	defer self.Deinit()
+
+	width, height := self.window.GetFramebufferSize()
+	err := self.initRendering(int32(width), int32(height))
 	if err != nil {
 		panic(err)
 	}
-	err = uiShader.SetUniform3fv("uColor", mgl32.Vec3{0.4, 0.4, 0.8})
+	defer self.deleteRendering()
+	self.renderer.Add(self.crosshair)
+	self.renderer.Add(self.ui)
+	self.renderer.Add(self.text)
+	self.source = &windowInput{window: self.window}
+	self.gamepad = &input.GamepadSource{Read: readGamepad}
+	self.applyGamepadMapping()
+	self.actions = input.NewMap(nil)
+	self.setupUiInput()
+
+	res, err := loadResources(true)
 	if err != nil {
 		panic(err)
 	}
-
-	var retVbo, retVao uint32
-	var retExt float32 = 4.0
-	retVertices := []float32{
-		-retExt, -retExt,
-		 retExt, -retExt,
-		-retExt,  retExt,
-		 retExt, -retExt,
-		 retExt,  retExt,
-		-retExt,  retExt,
+	self.useResources(res)
+	for _, pack := range self.res.packs {
+		fmt.Println("using resource pack:", pack.Manifest.Name)
 	}
-	gl.GenBuffers(1, &retVbo)
-	gl.GenVertexArrays(1, &retVao)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, retVbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(retVertices)*4, gl.Ptr(retVertices), gl.STATIC_DRAW)
+	self.applySettings()
+	self.registerCommands()
+	self.console = startConsole()
 
-	gl.BindVertexArray(retVao)
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, nil)
-
-	gl.BindVertexArray(0)
+	// the game starts on the title screen, with an empty world under the sky
+	self.world = world.World{Registry: self.res.blockRegistry, Time: world.TIME_NEW_WORLD}
+	self.menu = ui.NewRoot(nil, self.ui)
+	self.setupDisplay()
+	self.showTitleScreen()
+	defer self.saveSettings()
+	defer self.leaveWorld()
 
 	// Main loop timing
-	timePerTick := 1.0 / FPS
-	delta := 0.0
-	lastTime := glfw.GetTime()
+	timePerTick := 1.0 / TICK_RATE
+	tickDelta := 0.0
+	lastFrame := glfw.GetTime()
 
 	// Camera
-	aspectRatio := float64(WIDTH) / HEIGHT
-	fovx := math.Pi / 2.0
-	fovy := math.Atan(math.Tan(fovx/2) * aspectRatio)
-	projectionMatrix := mgl32.Perspective(float32(fovy), float32(aspectRatio), 0.001, 1000.0)
-
-	cameraPos := mgl32.Vec3{0.0, 0.0, -5.0}
 	cameraFront := mgl32.Vec3{0.0, 0.0, -1.0}
-	cameraSpeed := 2.5
 
 	px, py := self.window.GetCursorPos()
 	dx, dy := 0.0, 0.0
-	yaw, pitch := float64(mgl32.DegToRad(90.0)), 0.0
+	wasCaptured := false
 	maxPitch := float64(mgl32.DegToRad(89.0))
 
 	for !self.window.ShouldClose() {
 		now := glfw.GetTime()
-		delta += now - lastTime
-		lastTime = now
+		// the frame rate may be changed in the options
+		if self.settings.Video.FrameRate != UNLIMITED_FRAME_RATE {
+			timePerFrame := 1.0 / float64(self.settings.Video.FrameRate)
+			if wait := lastFrame + timePerFrame - now; wait > 0 {
+				time.Sleep(time.Duration(wait * float64(time.Second)))
+				continue
+			}
+		}
+		// one update and one draw per frame, moving things by the time the
+		// frame actually took
+		frameTime := math.Min(now-lastFrame, MAX_FRAME_TIME)
+		lastFrame = now
+
+		self.actions.Update(input.Sources{self.source, self.gamepad})
+		if self.latchInput {
+			self.actions.Latch()
+			self.latchInput = false
+		}
+		if self.gamepad.Used() {
+			self.setUsingGamepad(true)
+		}
+		self.gamepadMenuInput()
 
-		for delta >= timePerTick {
-			delta -= timePerTick
-			if self.window.GetKey(glfw.KeyEscape) == glfw.Press {
-				self.window.SetShouldClose(true)
+		if self.playing && self.actions.Pressed(game.ACTION_PAUSE) {
+			if self.paused {
+				self.resumeGame()
+			} else {
+				self.pauseGame()
 			}
+		}
 
-			mx, my := self.window.GetCursorPos()
-			dx, dy = mx-px, py-my
-			px, py = mx, my
+		if self.actions.Pressed(game.ACTION_RELOAD_ASSETS) {
+			self.reloadResources()
+		}
 
-			yaw += dx * LOOK_SENSITIVITY * timePerTick
-			pitch += dy * LOOK_SENSITIVITY * timePerTick
-			if pitch > maxPitch {
-				pitch = maxPitch
-			} else if pitch < -maxPitch {
-				pitch = -maxPitch
+		self.res.shaders.Poll()
+		self.runConsoleCommands()
+		if !self.paused {
+			tickDelta += frameTime
+			for tickDelta >= timePerTick {
+				tickDelta -= timePerTick
+				self.world.Tick()
 			}
+		}
 
-			cameraFront = mgl32.Vec3{
-				float32(math.Cos(yaw) * math.Cos(pitch)),
-				float32(math.Sin(pitch)),
-				float32(math.Sin(yaw) * math.Cos(pitch)),
-			}.Normalize()
-			cameraRight := cameraFront.Cross(render.WorldUp).Normalize()
-			cameraUp := cameraRight.Cross(cameraFront)
-
-			cameraTranslation := mgl32.Vec3{}
-			if self.window.GetKey(glfw.KeyW) == glfw.Press {
-				cameraTranslation = cameraTranslation.Add(cameraFront)
-			}
-			if self.window.GetKey(glfw.KeyS) == glfw.Press {
-				cameraTranslation = cameraTranslation.Sub(cameraFront)
-			}
-			if self.window.GetKey(glfw.KeyD) == glfw.Press {
-				cameraTranslation = cameraTranslation.Add(cameraRight)
-			}
-			if self.window.GetKey(glfw.KeyA) == glfw.Press {
-				cameraTranslation = cameraTranslation.Sub(cameraRight)
-			}
+		captured := self.playing && !self.paused
+		mx, my := self.window.GetCursorPos()
+		dx, dy = mx-px, py-my
+		px, py = mx, my
+		if !captured || !wasCaptured {
+			// the cursor moves freely over the menus, and jumps when it
+			// is captured again
+			dx, dy = 0, 0
+		}
+		wasCaptured = captured
 
-			cameraTranslation[1] = 0.0
-			if cameraTranslation.LenSqr() != 0.0 {
-				cameraTranslation = cameraTranslation.Normalize()
-			}
+		if self.settings.Controls.InvertMouse {
+			dy = -dy
+		}
+		// the mouse turns the camera by the distance it moved, which already
+		// covers the whole frame; the stick turns it at a speed
+		lookSpeed := LOOK_SENSITIVITY * self.settings.Controls.MouseSensitivity
+		self.camera.yaw += dx * lookSpeed
+		self.camera.pitch += dy * lookSpeed
+		if captured {
+			self.stickLook(frameTime)
+		}
+		if self.camera.pitch > maxPitch {
+			self.camera.pitch = maxPitch
+		} else if self.camera.pitch < -maxPitch {
+			self.camera.pitch = -maxPitch
+		}
 
-			if self.window.GetKey(glfw.KeySpace) == glfw.Press {
-				cameraTranslation = cameraTranslation.Add(render.WorldUp)
-			}
-			if self.window.GetKey(glfw.KeyLeftShift) == glfw.Press {
-				cameraTranslation = cameraTranslation.Sub(render.WorldUp)
+		cameraFront = mgl32.Vec3{
+			float32(math.Cos(self.camera.yaw) * math.Cos(self.camera.pitch)),
+			float32(math.Sin(self.camera.pitch)),
+			float32(math.Sin(self.camera.yaw) * math.Cos(self.camera.pitch)),
+		}.Normalize()
+		cameraRight := cameraFront.Cross(scene.WorldUp).Normalize()
+		cameraUp := cameraRight.Cross(cameraFront)
+
+		if captured {
+			direction := game.MoveDirection(self.actions, cameraFront, cameraRight).
+				Add(game.StickDirection(self.gamepad, cameraFront, cameraRight))
+			speed := self.settings.Gameplay.FlySpeed
+			if self.actions.Held(game.ACTION_SPRINT) {
+				speed *= self.settings.Gameplay.SprintMultiplier
 			}
+			self.camera.position = self.camera.position.Add(direction.Mul(float32(speed * frameTime)))
+		}
 
-			speedMult := 1.0
-			if self.window.GetKey(glfw.KeyLeftControl) == glfw.Press {
-				speedMult = 4.0
-			}
-			cameraPos = cameraPos.Add(cameraTranslation.Mul(float32(cameraSpeed * speedMult * timePerTick)))
-
-			// viewMatrix := mgl32.LookAtV(cameraPos, cameraPos.Add(cameraFront), render.WorldUp)
-			viewMatrix := mgl32.Mat4{
-				cameraRight[0], cameraUp[0], -cameraFront[0], 0,
-				cameraRight[1], cameraUp[1], -cameraFront[1], 0,
-				cameraRight[2], cameraUp[2], -cameraFront[2], 0,
-				-cameraRight.Dot(cameraPos), -cameraUp.Dot(cameraPos), cameraFront.Dot(cameraPos), 1,
-			}
+		// viewMatrix := mgl32.LookAtV(self.camera.position, self.camera.position.Add(cameraFront), scene.WorldUp)
+		viewMatrix := mgl32.Mat4{
+			cameraRight[0], cameraUp[0], -cameraFront[0], 0,
+			cameraRight[1], cameraUp[1], -cameraFront[1], 0,
+			cameraRight[2], cameraUp[2], -cameraFront[2], 0,
+			-cameraRight.Dot(self.camera.position), -cameraUp.Dot(self.camera.position), cameraFront.Dot(self.camera.position), 1,
+		}
 
-			// Raycast
-			dst := cameraPos.Add(cameraFront.Mul(6))
-			self.raycast.Init(
-				[3]float64{ float64(cameraPos[0]), float64(cameraPos[1]), float64(cameraPos[2]) },
-				[3]float64{ float64(dst[0]),       float64(dst[1]),       float64(dst[2]) },
-			)
-
-			// DEBUG: Raycast
-			printDebugInfo := false
-			if self.window.GetKey(glfw.KeyH) == glfw.Press {
-				if !self.flag {
-					printDebugInfo = true
-				}
-				self.flag = true
-			} else {
-				self.flag = false
-			}
+		// Raycast
+		dst := self.camera.position.Add(cameraFront.Mul(6))
+		self.raycast.Init(
+			[3]float64{ float64(self.camera.position[0]), float64(self.camera.position[1]), float64(self.camera.position[2]) },
+			[3]float64{ float64(dst[0]),       float64(dst[1]),       float64(dst[2]) },
+		)
 
-			if printDebugInfo {
-				fmt.Println("DEBUG: Raycast")
-				fmt.Println("From:")
-				fmt.Printf("%.2f %.2f %.2f\n",
-					float64(cameraPos[0]), float64(cameraPos[1]), float64(cameraPos[2]),
-				)
-				fmt.Println("To:")
-				fmt.Printf("%.2f %.2f %.2f\n",
-					float64(dst[0]), float64(dst[1]), float64(dst[2]),
-				)
-			}
+		if self.actions.Pressed(game.ACTION_TOGGLE_DEBUG) {
+			self.debug.visible = !self.debug.visible
+		}
 
-			raycastHit := false
-			var selModelMatrix mgl32.Mat4
-			var blockId world.BlockId
-
-			// Render
-
-			gl.ClearColor(0.068, 0.068, 0.098, 1.0)
-			gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
-			// object.Render(projectionMatrix, viewMatrix)
-			// self.worldRenderer.Render(projectionMatrix, viewMatrix)
-			shader.UseProgram()
-			shader.SetUniformMatrix4fv("uProjection", projectionMatrix)
-			shader.SetUniformMatrix4fv("uView", viewMatrix)
-			shader.SetUniformMatrix4fv("uModel", chunkMesh.ModelMatrix)
-			gl.ActiveTexture(gl.TEXTURE0)
-			gl.BindTexture(gl.TEXTURE_2D, commonDirtTexture)
-			gl.BindVertexArray(chunkMesh.Vao)
-			gl.DrawElements(gl.TRIANGLES, chunkMesh.ElementCount, gl.UNSIGNED_INT, nil)
-
-			for i := 0; i < 6; i++ {
-				blockId, err = self.world.BlockAt(int(self.raycast.X), int(self.raycast.Y), int(self.raycast.Z))
-
-				if printDebugInfo {
-					fmt.Println("Step", i)
-					fmt.Printf("Position: %d %d %d\n", self.raycast.X, self.raycast.Y, self.raycast.Z)
-					// fmt.Printf("Position: %.2f %.2f %.2f\n", self.raycast.X, self.raycast.Y, self.raycast.Z)
-					fmt.Printf("BlockId: %d Loaded: %v\n", blockId, err)
-				}
+		err = self.worldRenderer.BuildDirtyChunks(&self.world, self.res.blockTextures.Layers)
+		if err != nil {
+			fmt.Println("failed to rebuild chunk meshes:", err)
+		}
 
-				// TODO: Probably enable this once we're loading chunks around the player
-				// if err != nil {
-				// 	break
-				// }
-
-				// selectShader.UseProgram()
-				// selectShader.SetUniformMatrix4fv("uProjection", projectionMatrix)
-				// selectShader.SetUniformMatrix4fv("uView", viewMatrix)
-				// selModelMatrix = mgl32.Translate3D(float32(self.raycast.X), float32(self.raycast.Y), float32(self.raycast.Z))
-				// selectShader.SetUniformMatrix4fv("uModel", selModelMatrix)
-				// gl.Enable(gl.POLYGON_OFFSET_FILL)
-				// gl.PolygonOffset(-1.0, -1.0)
-				// gl.BindVertexArray(vao)
-				// gl.DrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, nil)
-				// gl.Disable(gl.POLYGON_OFFSET_FILL)
-
-				if blockId != 0 {
-					raycastHit = true
-					selModelMatrix = mgl32.Translate3D(float32(self.raycast.X), float32(self.raycast.Y), float32(self.raycast.Z))
-					break
+		target := game.Target{}
+		for i := 0; i < 6; i++ {
+			blockId, _ := self.world.BlockAt(int(self.raycast.X), int(self.raycast.Y), int(self.raycast.Z))
+
+			// TODO: Probably enable this once we're loading chunks around the player
+			// if err != nil {
+			// 	break
+			// }
+
+			if blockId != 0 {
+				target = game.Target{
+					Hit:   true,
+					Block: [3]int{int(self.raycast.X), int(self.raycast.Y), int(self.raycast.Z)},
+					Id:    blockId,
+					Face:  self.raycast.Face,
 				}
-
-				self.raycast.Step()
-			}
-			// _ = raycastHit
-			if raycastHit {
-				selectShader.UseProgram()
-				selectShader.SetUniformMatrix4fv("uProjection", projectionMatrix)
-				selectShader.SetUniformMatrix4fv("uView", viewMatrix)
-				selectShader.SetUniformMatrix4fv("uModel", selModelMatrix)
-				gl.Enable(gl.POLYGON_OFFSET_FILL)
-				gl.PolygonOffset(-1.0, -1.0)
-				gl.BindVertexArray(vao)
-				gl.DrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, nil)
-				gl.Disable(gl.POLYGON_OFFSET_FILL)
+				break
 			}
 
-			uiShader.UseProgram()
-			gl.BindVertexArray(retVao)
-			gl.DrawArrays(gl.TRIANGLES, 0, 6)
+			self.raycast.Step()
+		}
 
-			gl.BindVertexArray(0)
+		self.selection.Visible = target.Hit
+		self.selection.Block = target.Block
+		if captured {
+			self.player.UseBlocks(self.actions, &self.world, self.camera.position, target)
+		}
+
+		// Render
 
-			self.window.SwapBuffers()
+		width, height := self.window.GetFramebufferSize()
+		if width == 0 || height == 0 {
+			// minimised, there is nothing to draw
 			glfw.PollEvents()
+			continue
+		}
+		self.debug.frame(now)
+		if self.playing && self.debug.visible {
+			self.drawDebugOverlay(self.camera.position, cameraFront, self.camera.yaw, self.camera.pitch, target)
+		}
+
+		if self.playing {
+			self.drawHeldBlock(height)
+		}
+		cameraBlock, _ := self.world.BlockAt(int(math.Floor(float64(self.camera.position[0]))), int(math.Floor(float64(self.camera.position[1]))), int(math.Floor(float64(self.camera.position[2]))))
+		// the framebuffer follows the window as it is resized
+		aspectRatio := float64(width) / float64(height)
+		fovy := self.settings.fovy(aspectRatio)
+		frame := render.FrameContext{
+			Projection:     perspective(float32(fovy), float32(aspectRatio)),
+			View:           viewMatrix,
+			CameraPosition: self.camera.position,
+			Fovy:           float32(fovy),
+			Aspect:         float32(aspectRatio),
+			Width:          int32(width),
+			Height:         int32(height),
+			Time:           now,
+			Sky:            scene.NewSkyState(self.world.TimeOfDay()),
+			Underwater:     self.world.Registry.IsLiquid(cameraBlock),
 		}
+		err = self.renderer.Render(&frame)
+		if err != nil {
+			panic(err)
+		}
+
+		self.window.SwapBuffers()
+		glfw.PollEvents()
 	}
 }
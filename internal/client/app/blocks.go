@@ -0,0 +1,18 @@
+package app
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// distance of the held block name from the bottom-left corner, in pixels
+const HELD_BLOCK_MARGIN = 4
+
+var HELD_BLOCK_COLOR = mgl32.Vec4{1, 1, 1, 1}
+
+// Draws the name of the block placed in the bottom-left corner.
+func (self *App) drawHeldBlock(height int) {
+	held := self.player.HeldBlockDef(self.world.Registry)
+	if held == nil {
+		return
+	}
+	_, textHeight := self.text.Measure(held.Name)
+	self.text.Draw(held.Name, HELD_BLOCK_MARGIN, float32(height)-textHeight-HELD_BLOCK_MARGIN, HELD_BLOCK_COLOR)
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hexagon-0/voxel-game/internal/common/command"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+var namedTimes = map[string]int64{
+	"sunrise":  world.TIME_SUNRISE,
+	"day":      world.TIME_SUNRISE + world.DAY_LENGTH/24,
+	"noon":     world.TIME_NOON,
+	"sunset":   world.TIME_SUNSET,
+	"night":    world.TIME_SUNSET + world.DAY_LENGTH/24,
+	"midnight": world.TIME_MIDNIGHT,
+}
+
+func (self *App) registerCommands() {
+	self.commands = command.NewRegistry()
+	self.commands.Register("time", "time set <ticks|sunrise|day|noon|sunset|night|midnight> | time add <ticks> | time query", self.timeCommand)
+	self.commands.Register("post", "post list | post <pass> on|off", self.postCommand)
+}
+
+// Runs the commands typed in the console since the last tick.
+func (self *App) runConsoleCommands() {
+	for {
+		select {
+		case line, ok := <-self.console:
+			if !ok {
+				self.console = nil
+				return
+			}
+
+			message, err := self.commands.Execute(line)
+			if err != nil {
+				fmt.Println(err)
+			} else if message != "" {
+				fmt.Println(message)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (self *App) timeCommand(args []string) (string, error) {
+	if len(args) == 1 && args[0] == "query" {
+		ticks := int64(self.world.TimeOfDay() * world.DAY_LENGTH)
+		return fmt.Sprintf("day %d, time %d", self.world.Day(), ticks), nil
+	}
+
+	if len(args) != 2 {
+		return "", fmt.Errorf("wrong number of arguments")
+	}
+
+	switch args[0] {
+	case "set":
+		ticks, ok := namedTimes[args[1]]
+		if !ok {
+			var err error
+			ticks, err = strconv.ParseInt(args[1], 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid time `%s`", args[1])
+			}
+		}
+		self.world.SetTimeOfDay(ticks)
+	case "add":
+		ticks, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || ticks < 0 {
+			return "", fmt.Errorf("invalid number of ticks `%s`", args[1])
+		}
+		self.world.Time += ticks
+	default:
+		return "", fmt.Errorf("unknown subcommand `%s`", args[0])
+	}
+
+	return fmt.Sprintf("time is now %d", int64(self.world.TimeOfDay()*world.DAY_LENGTH)), nil
+}
+
+func (self *App) postCommand(args []string) (string, error) {
+	if len(args) == 1 && args[0] == "list" {
+		lines := make([]string, 0, len(self.renderer.Post.Passes))
+		for _, pass := range self.renderer.Post.Passes {
+			state := "off"
+			if pass.Enabled {
+				state = "on"
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", pass.Name, state))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	if len(args) != 2 {
+		return "", fmt.Errorf("expected a pass and on|off")
+	}
+	if args[1] != "on" && args[1] != "off" {
+		return "", fmt.Errorf("expected on|off, got `%s`", args[1])
+	}
+
+	err := self.renderer.Post.SetEnabled(args[0], args[1] == "on")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s is now %s", args[0], args[1]), nil
+}
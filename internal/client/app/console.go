@@ -0,0 +1,20 @@
+package app
+
+import (
+	"bufio"
+	"os"
+)
+
+// Reads command lines typed in the terminal on a separate goroutine, so the
+// main loop can run them between ticks without blocking.
+func startConsole() <-chan string {
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	return lines
+}
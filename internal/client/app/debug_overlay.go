@@ -0,0 +1,104 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/game"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+const (
+	// seconds of frames averaged for the frame rate shown by the overlay
+	DEBUG_FPS_WINDOW = 1.0
+	// distance of the overlay from the top-left corner, in pixels
+	DEBUG_MARGIN = 4
+)
+
+var DEBUG_TEXT_COLOR = mgl32.Vec4{1, 1, 1, 1}
+
+// debugOverlay is the F3 screen, listing the state of the camera, the world
+// and the renderer in the top-left corner.
+type debugOverlay struct {
+	visible bool
+	// start times of the frames of the last DEBUG_FPS_WINDOW seconds
+	frames []float64
+}
+
+// Records a frame drawn at now, in seconds.
+func (self *debugOverlay) frame(now float64) {
+	i := 0
+	for i < len(self.frames) && self.frames[i] < now-DEBUG_FPS_WINDOW {
+		i++
+	}
+	self.frames = append(self.frames[:copy(self.frames, self.frames[i:])], now)
+}
+
+// Average frame rate and frame time, in seconds, over the recorded frames.
+func (self *debugOverlay) frameRate() (fps, frameTime float64) {
+	n := len(self.frames)
+	if n < 2 {
+		return 0, 0
+	}
+	frameTime = (self.frames[n-1] - self.frames[0]) / float64(n-1)
+	if frameTime == 0 {
+		return 0, 0
+	}
+	return 1 / frameTime, frameTime
+}
+
+// Axis the camera faces the most along, on the horizontal plane.
+func facingName(front mgl32.Vec3) string {
+	if math.Abs(float64(front[0])) > math.Abs(float64(front[2])) {
+		if front[0] > 0 {
+			return "+x"
+		}
+		return "-x"
+	}
+	if front[2] > 0 {
+		return "+z"
+	}
+	return "-z"
+}
+
+// Queues the overlay text for the frame. yaw and pitch are in radians.
+func (self *App) drawDebugOverlay(position, front mgl32.Vec3, yaw, pitch float64, target game.Target) {
+	fps, frameTime := self.debug.frameRate()
+	block := [3]int{
+		int(math.Floor(float64(position[0]))),
+		int(math.Floor(float64(position[1]))),
+		int(math.Floor(float64(position[2]))),
+	}
+
+	lines := []string{
+		fmt.Sprintf("%.0f fps (%.2f ms)", fps, frameTime*1000),
+		fmt.Sprintf("Position: %.3f / %.3f / %.3f", position[0], position[1], position[2]),
+		fmt.Sprintf("Block: %d %d %d", block[0], block[1], block[2]),
+		fmt.Sprintf("Chunk: %d %d %d (at %d %d %d)",
+			block[0]>>world.CHUNK_SHIFT, block[1]>>world.CHUNK_SHIFT, block[2]>>world.CHUNK_SHIFT,
+			block[0]&(world.CHUNK_SIZE-1), block[1]&(world.CHUNK_SIZE-1), block[2]&(world.CHUNK_SIZE-1),
+		),
+		fmt.Sprintf("Facing: %s (yaw %.1f, pitch %.1f)",
+			facingName(front), mgl32.RadToDeg(float32(yaw)), mgl32.RadToDeg(float32(pitch)),
+		),
+		fmt.Sprintf("Chunks: %d loaded, %d meshed, %d drawn",
+			len(self.world.Chunks), self.worldRenderer.Arena.Len(), self.worldRenderer.DrawCount,
+		),
+	}
+
+	if target.Hit {
+		name := "unknown"
+		if def := self.world.Registry.Get(target.Id); def != nil {
+			name = def.Name
+		}
+		lines = append(lines, fmt.Sprintf("Target: %d %d %d, %s (id %d), face %s",
+			target.Block[0], target.Block[1], target.Block[2], name, target.Id, world.FaceName(target.Face),
+		))
+	} else {
+		lines = append(lines, "Target: none")
+	}
+
+	self.text.Draw(strings.Join(lines, "\n"), DEBUG_MARGIN, DEBUG_MARGIN, DEBUG_TEXT_COLOR)
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// How the window is shown, as saved in the settings.
+const (
+	DISPLAY_WINDOWED   = "windowed"
+	DISPLAY_FULLSCREEN = "fullscreen"
+	// a window without decorations covering the whole monitor, without
+	// changing its video mode
+	DISPLAY_BORDERLESS = "borderless"
+)
+
+// Display modes in the order the video options cycle through them.
+var DISPLAY_MODES = []string{DISPLAY_WINDOWED, DISPLAY_FULLSCREEN, DISPLAY_BORDERLESS}
+
+// Frame rate setting that draws frames as fast as possible, or as the
+// monitor refreshes with vertical sync.
+const UNLIMITED_FRAME_RATE = 0
+
+// Sizes of the interface at a content scale of 1: pixels per UI unit, pixels
+// per font pixel of the text drawn over the world, and how far the crosshair
+// extends from the centre of the screen.
+const (
+	UI_SCALE       = 2
+	TEXT_SCALE     = 2
+	CROSSHAIR_SIZE = 4
+)
+
+// Window state the display settings were last applied with.
+type displayState struct {
+	mode    string
+	monitor int
+	width   int
+	height  int
+	vsync   bool
+}
+
+func isDisplayMode(mode string) bool {
+	for _, known := range DISPLAY_MODES {
+		if mode == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Label of a display mode in the video options.
+func displayModeLabel(mode string) string {
+	switch mode {
+	case DISPLAY_FULLSCREEN:
+		return "Fullscreen"
+	case DISPLAY_BORDERLESS:
+		return "Borderless"
+	}
+	return "Windowed"
+}
+
+// Monitor of the settings, by its index in the monitors GLFW lists; the
+// primary monitor if it is not connected.
+func monitorAt(index int) *glfw.Monitor {
+	monitors := glfw.GetMonitors()
+	if index >= 0 && index < len(monitors) {
+		return monitors[index]
+	}
+	return glfw.GetPrimaryMonitor()
+}
+
+// Label of a monitor in the video options.
+func monitorLabel(index int) string {
+	monitors := glfw.GetMonitors()
+	if index < 0 || index >= len(monitors) {
+		return fmt.Sprintf("%d (disconnected)", index+1)
+	}
+	return fmt.Sprintf("%d (%s)", index+1, monitors[index].GetName())
+}
+
+// Sets the window hints of the display, before the window is created.
+func displayHints() {
+	// full resolution framebuffers on macOS; the window size stays in screen
+	// coordinates everywhere, and the interface follows the content scale
+	glfw.WindowHint(glfw.CocoaRetinaFramebuffer, glfw.True)
+}
+
+// Shows the window as the display settings say, if they changed since they
+// were last applied.
+func (self *App) applyDisplay() {
+	video := self.settings.Video
+	state := displayState{
+		mode:    video.DisplayMode,
+		monitor: video.Monitor,
+		width:   video.Width,
+		height:  video.Height,
+		vsync:   video.VSync,
+	}
+	if state == self.display {
+		return
+	}
+	previous := self.display
+	self.display = state
+
+	if state.vsync != previous.vsync || previous.mode == "" {
+		if state.vsync {
+			glfw.SwapInterval(1)
+		} else {
+			glfw.SwapInterval(0)
+		}
+	}
+	if state.mode == previous.mode && state.monitor == previous.monitor &&
+		(state.mode != DISPLAY_WINDOWED || state.width == previous.width && state.height == previous.height) {
+		return
+	}
+
+	monitor := monitorAt(state.monitor)
+	videoMode := monitor.GetVideoMode()
+	switch state.mode {
+	case DISPLAY_FULLSCREEN:
+		self.window.SetMonitor(monitor, 0, 0, videoMode.Width, videoMode.Height, videoMode.RefreshRate)
+	case DISPLAY_BORDERLESS:
+		x, y := monitor.GetPos()
+		self.window.SetAttrib(glfw.Decorated, glfw.False)
+		self.window.SetMonitor(nil, x, y, videoMode.Width, videoMode.Height, 0)
+	default:
+		// centred on the work area of the monitor
+		x, y, width, height := monitor.GetWorkarea()
+		self.window.SetAttrib(glfw.Decorated, glfw.True)
+		self.window.SetMonitor(nil, x+(width-state.width)/2, y+(height-state.height)/2, state.width, state.height, 0)
+	}
+}
+
+// Scales the interface by the content scale of the window, so it keeps its
+// size on high DPI screens.
+func (self *App) applyContentScale() {
+	scale, _ := self.window.GetContentScale()
+	if scale <= 0 {
+		scale = 1
+	}
+	self.menu.Scale = UI_SCALE * scale
+	self.text.Scale = TEXT_SCALE * scale
+	self.crosshair.Scale = scale
+}
+
+// Installs the window callbacks following its size and content scale.
+func (self *App) setupDisplay() {
+	self.window.SetSizeLimits(MIN_WINDOW_WIDTH, MIN_WINDOW_HEIGHT, glfw.DontCare, glfw.DontCare)
+
+	self.window.SetFramebufferSizeCallback(func(window *glfw.Window, width, height int) {
+		gl.Viewport(0, 0, int32(width), int32(height))
+	})
+
+	// the size of a window resized by hand is kept for the next run, unless
+	// it was maximised
+	self.window.SetSizeCallback(func(window *glfw.Window, width, height int) {
+		if self.display.mode != DISPLAY_WINDOWED || width == 0 || height == 0 ||
+			window.GetAttrib(glfw.Maximized) == glfw.True {
+			return
+		}
+		if width == self.settings.Video.Width && height == self.settings.Video.Height {
+			return
+		}
+		self.display.width, self.display.height = width, height
+		self.changeSettings(func(settings *Settings) {
+			settings.Video.Width, settings.Video.Height = width, height
+		})
+	})
+
+	self.window.SetContentScaleCallback(func(window *glfw.Window, x, y float32) {
+		self.applyContentScale()
+	})
+	self.applyContentScale()
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+)
+
+// Names of the gamepad files in the user configuration directory: the
+// mapping of the gamepad to the game, and an optional SDL controller
+// database for gamepads GLFW doesn't know.
+const (
+	GAMEPAD_FILE       = "gamepad.json"
+	CONTROLLER_DB_FILE = "gamecontrollerdb.txt"
+)
+
+// Limits of the gamepad mapping; values outside them are clamped when loaded.
+const (
+	MAX_DEADZONE = 0.9
+
+	MIN_STICK_CURVE = 0.5
+	MAX_STICK_CURVE = 4
+
+	MIN_TRIGGER_THRESHOLD = 0.05
+
+	MIN_STICK_LOOK_SPEED = 0.5
+	MAX_STICK_LOOK_SPEED = 10
+)
+
+// GamepadMapping is how a gamepad drives the game, read from gamepad.json.
+type GamepadMapping struct {
+	// fraction of the stick range ignored around the centre
+	Deadzone float64 `json:"deadzone"`
+	// exponent of the stick response, above 1 for finer control near the
+	// centre
+	Curve float64 `json:"curve"`
+	// how far a trigger must be pulled to count as pressed, from 0 to 1
+	TriggerThreshold float64 `json:"triggerThreshold"`
+	// radians per second the camera turns with the right stick fully tilted
+	LookSpeed  float64 `json:"lookSpeed"`
+	InvertLook bool    `json:"invertLook"`
+	// gamepad button of every action, by action name, like "jump":
+	// "gamepad:a"; an empty button leaves the action unbound
+	Bindings map[string]string `json:"bindings"`
+}
+
+func DefaultGamepadMapping() GamepadMapping {
+	return GamepadMapping{
+		Deadzone:         0.15,
+		Curve:            2,
+		TriggerThreshold: 0.5,
+		LookSpeed:        3,
+		Bindings:         defaultGamepadBindings(),
+	}
+}
+
+// Validate clamps the mapping into its limits and drops invalid bindings,
+// returning an error listing what was invalid.
+func (self *GamepadMapping) Validate() error {
+	problems := settingProblems{}
+	problems.clampFloat("deadzone", &self.Deadzone, 0, MAX_DEADZONE)
+	problems.clampFloat("curve", &self.Curve, MIN_STICK_CURVE, MAX_STICK_CURVE)
+	problems.clampFloat("triggerThreshold", &self.TriggerThreshold, MIN_TRIGGER_THRESHOLD, 1)
+	problems.clampFloat("lookSpeed", &self.LookSpeed, MIN_STICK_LOOK_SPEED, MAX_STICK_LOOK_SPEED)
+	if self.Bindings == nil {
+		self.Bindings = map[string]string{}
+	}
+
+	defaults := defaultBindings()
+	actions := make([]string, 0, len(self.Bindings))
+	for action := range self.Bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		binding := self.Bindings[action]
+		if _, ok := defaults[action]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown action `%s`", action))
+			delete(self.Bindings, action)
+			continue
+		}
+		if binding == "" {
+			continue
+		}
+		button, err := input.ParseButton(binding)
+		if err == nil && (button.Device != input.DEVICE_GAMEPAD || !input.IsGamepadButton(button.Name)) {
+			err = fmt.Errorf("unknown gamepad button `%s`", binding)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("binding of `%s`: %s", action, err))
+			delete(self.Bindings, action)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid gamepad mapping: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LoadGamepadMapping reads the mapping file at path over the defaults, like
+// LoadSettings.
+func LoadGamepadMapping(path string) (GamepadMapping, error) {
+	mapping := DefaultGamepadMapping()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return mapping, nil
+	}
+	if err != nil {
+		return mapping, err
+	}
+
+	err = json.Unmarshal(data, &mapping)
+	if err != nil {
+		return DefaultGamepadMapping(), fmt.Errorf("failed to parse `%s`: %w", path, err)
+	}
+	return mapping, mapping.Validate()
+}
+
+// Adds the gamepads of the SDL controller database in the configuration
+// directory, if there is one, to those GLFW knows.
+func loadControllerDb() error {
+	path := configPath(CONTROLLER_DB_FILE)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !glfw.UpdateGamepadMappings(string(data)) {
+		return fmt.Errorf("failed to parse `%s`", path)
+	}
+	return nil
+}
+
+// Reads the first connected joystick GLFW recognises as a gamepad.
+func readGamepad() (input.GamepadState, bool) {
+	for joystick := glfw.Joystick1; joystick <= glfw.JoystickLast; joystick++ {
+		if !joystick.IsGamepad() {
+			continue
+		}
+		state := joystick.GetGamepadState()
+		if state == nil {
+			continue
+		}
+
+		gamepad := input.GamepadState{}
+		for i := range gamepad.Buttons {
+			gamepad.Buttons[i] = state.Buttons[i] == glfw.Press
+		}
+		copy(gamepad.Axes[:], state.Axes[:])
+		// GLFW triggers rest at -1
+		gamepad.Axes[input.AXIS_LEFT_TRIGGER] = (gamepad.Axes[input.AXIS_LEFT_TRIGGER] + 1) / 2
+		gamepad.Axes[input.AXIS_RIGHT_TRIGGER] = (gamepad.Axes[input.AXIS_RIGHT_TRIGGER] + 1) / 2
+		return gamepad, true
+	}
+	return input.GamepadState{}, false
+}
+
+// Applies the gamepad mapping to the gamepad source.
+func (self *App) applyGamepadMapping() {
+	self.gamepad.TriggerThreshold = float32(self.padMapping.TriggerThreshold)
+	self.gamepad.Response = input.StickResponse{
+		Deadzone: float32(self.padMapping.Deadzone),
+		Curve:    float32(self.padMapping.Curve),
+	}
+}
+
+// Turns the camera with the right stick of the gamepad.
+func (self *App) stickLook(frameTime float64) {
+	x, y := self.gamepad.Stick(true)
+	if self.padMapping.InvertLook {
+		y = -y
+	}
+	self.camera.yaw += float64(x) * self.padMapping.LookSpeed * frameTime
+	// the stick points down for positive y
+	self.camera.pitch -= float64(y) * self.padMapping.LookSpeed * frameTime
+}
+
+// Switches between showing the cursor and the gamepad focus on the menus
+// when the player picks up the other device.
+func (self *App) setUsingGamepad(using bool) {
+	if self.usingGamepad == using {
+		return
+	}
+	self.usingGamepad = using
+	if self.ui.Root != nil {
+		self.window.SetInputMode(glfw.CursorMode, self.menuCursorMode())
+	}
+}
+
+// Cursor mode over the menus: hidden while playing with a gamepad.
+func (self *App) menuCursorMode() int {
+	if self.usingGamepad {
+		return glfw.CursorHidden
+	}
+	return glfw.CursorNormal
+}
+
+// Gamepad buttons sent to the menus as keys: the D-pad moves around and A
+// activates. Up and down move the focus when the focused widget doesn't use
+// them.
+var gamepadMenuKeys = map[int]ui.Key{
+	input.GAMEPAD_DPAD_UP:    ui.KEY_UP,
+	input.GAMEPAD_DPAD_DOWN:  ui.KEY_DOWN,
+	input.GAMEPAD_DPAD_LEFT:  ui.KEY_LEFT,
+	input.GAMEPAD_DPAD_RIGHT: ui.KEY_RIGHT,
+	input.GAMEPAD_A:          ui.KEY_ENTER,
+}
+
+// Drives the menus with the gamepad.
+func (self *App) gamepadMenuInput() {
+	if self.ui.Root == nil {
+		return
+	}
+	for button, key := range gamepadMenuKeys {
+		if !self.gamepad.Pressed(button) {
+			continue
+		}
+		if self.ui.Root.HandleEvent(ui.Event{Kind: ui.EVENT_KEY, Key: key}) {
+			continue
+		}
+		if key == ui.KEY_UP || key == ui.KEY_DOWN {
+			self.ui.Root.FocusNext(key == ui.KEY_UP)
+		}
+	}
+}
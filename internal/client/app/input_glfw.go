@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+)
+
+// Names of the keys that can be bound, as saved in the settings.
+var keyNames = map[glfw.Key]string{
+	glfw.KeySpace:        "Space",
+	glfw.KeyApostrophe:   "'",
+	glfw.KeyComma:        ",",
+	glfw.KeyMinus:        "-",
+	glfw.KeyPeriod:       ".",
+	glfw.KeySlash:        "/",
+	glfw.KeySemicolon:    ";",
+	glfw.KeyEqual:        "=",
+	glfw.KeyLeftBracket:  "[",
+	glfw.KeyBackslash:    "\\",
+	glfw.KeyRightBracket: "]",
+	glfw.KeyGraveAccent:  "`",
+	glfw.KeyEscape:       "Escape",
+	glfw.KeyEnter:        "Enter",
+	glfw.KeyTab:          "Tab",
+	glfw.KeyBackspace:    "Backspace",
+	glfw.KeyInsert:       "Insert",
+	glfw.KeyDelete:       "Delete",
+	glfw.KeyRight:        "Right",
+	glfw.KeyLeft:         "Left",
+	glfw.KeyDown:         "Down",
+	glfw.KeyUp:           "Up",
+	glfw.KeyPageUp:       "PageUp",
+	glfw.KeyPageDown:     "PageDown",
+	glfw.KeyHome:         "Home",
+	glfw.KeyEnd:          "End",
+	glfw.KeyCapsLock:     "CapsLock",
+	glfw.KeyLeftShift:    "LeftShift",
+	glfw.KeyLeftControl:  "LeftControl",
+	glfw.KeyLeftAlt:      "LeftAlt",
+	glfw.KeyRightShift:   "RightShift",
+	glfw.KeyRightControl: "RightControl",
+	glfw.KeyRightAlt:     "RightAlt",
+}
+
+// Names of the mouse buttons that can be bound.
+var mouseButtonNames = map[glfw.MouseButton]string{
+	glfw.MouseButtonLeft:   "left",
+	glfw.MouseButtonRight:  "right",
+	glfw.MouseButtonMiddle: "middle",
+	glfw.MouseButton4:      "4",
+	glfw.MouseButton5:      "5",
+}
+
+var keysByName = map[string]glfw.Key{}
+var mouseButtonsByName = map[string]glfw.MouseButton{}
+
+func init() {
+	// letters, digits and function keys are named after themselves
+	for key := glfw.KeyA; key <= glfw.KeyZ; key++ {
+		keyNames[key] = string(rune('A' + key - glfw.KeyA))
+	}
+	for key := glfw.Key0; key <= glfw.Key9; key++ {
+		keyNames[key] = string(rune('0' + key - glfw.Key0))
+	}
+	for key := glfw.KeyF1; key <= glfw.KeyF12; key++ {
+		keyNames[key] = fmt.Sprintf("F%d", key-glfw.KeyF1+1)
+	}
+
+	for key, name := range keyNames {
+		keysByName[name] = key
+	}
+	for button, name := range mouseButtonNames {
+		mouseButtonsByName[name] = button
+	}
+}
+
+// Whether the window input source knows the button.
+func knownButton(button input.Button) bool {
+	switch button.Device {
+	case input.DEVICE_KEY:
+		_, ok := keysByName[button.Name]
+		return ok
+	case input.DEVICE_MOUSE:
+		_, ok := mouseButtonsByName[button.Name]
+		return ok
+	case input.DEVICE_SCROLL:
+		return button.Name == input.SCROLL_UP || button.Name == input.SCROLL_DOWN
+	}
+	return false
+}
+
+// Name of a button shown to the player.
+func buttonLabel(button input.Button) string {
+	switch button.Device {
+	case input.DEVICE_MOUSE:
+		return "Mouse " + button.Name
+	case input.DEVICE_SCROLL:
+		return "Scroll " + button.Name
+	}
+	return button.Name
+}
+
+// Button of a key, and whether it can be bound.
+func keyButton(key glfw.Key) (input.Button, bool) {
+	name, ok := keyNames[key]
+	return input.Key(name), ok
+}
+
+// Button of a mouse button, and whether it can be bound.
+func mouseButton(button glfw.MouseButton) (input.Button, bool) {
+	name, ok := mouseButtonNames[button]
+	return input.Mouse(name), ok
+}
+
+// Name of the scroll button of a scroll offset.
+func scrollName(offset float64) string {
+	if offset > 0 {
+		return input.SCROLL_UP
+	}
+	return input.SCROLL_DOWN
+}
+
+// windowInput is the input.Source reading the keyboard and mouse of the game
+// window. Scrolling is added up by the scroll callback between polls.
+type windowInput struct {
+	window *glfw.Window
+	// scrolling since the last poll, and during the current update
+	pending float64
+	scroll  float64
+}
+
+func (self *windowInput) addScroll(amount float64) {
+	self.pending += amount
+}
+
+func (self *windowInput) Poll() {
+	self.scroll, self.pending = self.pending, 0
+}
+
+func (self *windowInput) Down(button input.Button) bool {
+	switch button.Device {
+	case input.DEVICE_KEY:
+		key, ok := keysByName[button.Name]
+		return ok && self.window.GetKey(key) == glfw.Press
+	case input.DEVICE_MOUSE:
+		mouse, ok := mouseButtonsByName[button.Name]
+		return ok && self.window.GetMouseButton(mouse) == glfw.Press
+	}
+	return false
+}
+
+func (self *windowInput) Scrolled() float64 {
+	return self.scroll
+}
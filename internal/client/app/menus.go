@@ -0,0 +1,378 @@
+package app
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+const (
+	// width of the menu panels, in UI units
+	MENU_WIDTH = 260
+	// longest world name accepted
+	WORLD_NAME_LENGTH = 32
+	// name suggested for new worlds
+	DEFAULT_WORLD_NAME = "New World"
+)
+
+var ERROR_TEXT_COLOR = mgl32.Vec4{1, 0.4, 0.4, 1}
+
+// Shows a menu screen and frees the cursor to use it.
+func (self *App) showScreen(content ui.Widget) {
+	self.rebind = nil
+	self.menu.SetContent(content)
+	self.ui.Root = self.menu
+	self.crosshair.Visible = false
+	self.window.SetInputMode(glfw.CursorMode, self.menuCursorMode())
+}
+
+// Hides the menus and captures the cursor to look around.
+func (self *App) hideScreen() {
+	self.rebind = nil
+	self.ui.Root = nil
+	self.crosshair.Visible = true
+	self.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+}
+
+// A titled panel in the middle of the screen.
+func menuScreen(title string, widgets ...ui.Widget) ui.Widget {
+	label := ui.NewLabel(title)
+	label.Align = ui.ALIGN_CENTER
+	panel := ui.NewPanel(append([]ui.Widget{label, ui.NewSized(0, 4, nil)}, widgets...)...)
+	return ui.NewCenter(ui.NewSized(MENU_WIDTH, 0, panel))
+}
+
+// A label for error messages, empty until there is one.
+func errorLabel() *ui.Label {
+	label := ui.NewLabel("")
+	label.Color = ERROR_TEXT_COLOR
+	label.Align = ui.ALIGN_CENTER
+	return label
+}
+
+// Buttons side by side, sharing the width of the panel.
+func buttonRow(buttons ...*ui.Button) ui.Widget {
+	theme := ui.DefaultTheme()
+	// panels are padded by twice the theme padding on each side
+	inner := MENU_WIDTH - 4*theme.Padding
+	width := (inner - float32(len(buttons)-1)*theme.Spacing) / float32(len(buttons))
+	row := ui.NewHBox()
+	for _, button := range buttons {
+		row.Add(ui.NewSized(width, 0, button))
+	}
+	return row
+}
+
+func (self *App) showTitleScreen() {
+	self.showScreen(menuScreen("Voxel Game",
+		ui.NewButton("Singleplayer", self.showWorldsScreen),
+		ui.NewButton("Options", func() {
+			self.showOptionsScreen(self.showTitleScreen)
+		}),
+		ui.NewButton("Quit", func() {
+			self.window.SetShouldClose(true)
+		}),
+	))
+}
+
+// One line describing a saved world in the world list.
+func describeSave(save world.Save) string {
+	played := "never played"
+	if save.Info.LastPlayed != 0 {
+		played = time.Unix(save.Info.LastPlayed, 0).Format("2006-01-02 15:04")
+	}
+	generator := save.Info.Generator
+	if generator == "" {
+		generator = world.GENERATOR_DEFAULT
+	}
+	mode := save.Info.GameMode
+	if mode == "" {
+		mode = world.GAME_MODE_SURVIVAL
+	}
+	return fmt.Sprintf("%s  %s, %s, %s", save.Name(), mode, generator, played)
+}
+
+// Lists the saved worlds, to play, rename or delete them, or create one.
+func (self *App) showWorldsScreen() {
+	status := errorLabel()
+	saves, err := world.ListSaves(savesDir())
+	if err != nil {
+		status.Text = "Failed to list worlds"
+		fmt.Println("failed to list worlds:", err)
+	}
+
+	items := make([]string, len(saves))
+	for i, save := range saves {
+		items[i] = describeSave(save)
+	}
+	list := ui.NewList(items)
+	list.Rows = 8
+
+	play := ui.NewButton("Play", nil)
+	rename := ui.NewButton("Rename", nil)
+	remove := ui.NewButton("Delete", nil)
+	selected := func() (world.Save, bool) {
+		if list.Selected < 0 || list.Selected >= len(saves) {
+			return world.Save{}, false
+		}
+		return saves[list.Selected], true
+	}
+	updateButtons := func(index int) {
+		_, ok := selected()
+		play.Disabled, rename.Disabled, remove.Disabled = !ok, !ok, !ok
+	}
+	list.OnSelect = updateButtons
+	if len(saves) > 0 {
+		list.Selected = 0
+	}
+	updateButtons(list.Selected)
+
+	playSelected := func() {
+		if save, ok := selected(); ok {
+			err := self.enterWorld(save.Dir)
+			if err != nil {
+				status.Text = "Failed to load the world"
+				fmt.Println("failed to load world:", err)
+			}
+		}
+	}
+	play.OnClick = playSelected
+	list.OnActivate = func(index int) {
+		playSelected()
+	}
+	rename.OnClick = func() {
+		if save, ok := selected(); ok {
+			self.showRenameWorldScreen(save)
+		}
+	}
+	remove.OnClick = func() {
+		if save, ok := selected(); ok {
+			self.showDeleteWorldScreen(save)
+		}
+	}
+
+	self.showScreen(menuScreen("Select World",
+		list,
+		status,
+		buttonRow(play, ui.NewButton("Create New", self.showCreateWorldScreen)),
+		buttonRow(rename, remove, ui.NewButton("Back", self.showTitleScreen)),
+	))
+	self.menu.Focus(list)
+}
+
+// Button cycling through a list of options, showing the current one after
+// its label.
+func optionButton(label string, options []string, selected *int) *ui.Button {
+	button := ui.NewButton("", nil)
+	update := func() {
+		button.Text = label + ": " + options[*selected]
+	}
+	button.OnClick = func() {
+		*selected = (*selected + 1) % len(options)
+		update()
+	}
+	update()
+	return button
+}
+
+func (self *App) showCreateWorldScreen() {
+	status := errorLabel()
+
+	name := ui.NewTextField(DEFAULT_WORLD_NAME)
+	name.MaxLength = WORLD_NAME_LENGTH
+	name.SetText(DEFAULT_WORLD_NAME)
+	seed := ui.NewTextField("Random")
+
+	generator, mode := 0, 0
+	create := func() {
+		info := world.WorldInfo{
+			Name:      strings.TrimSpace(name.Text),
+			Seed:      rand.Int63(),
+			Generator: world.GENERATORS[generator],
+			GameMode:  world.GAME_MODES[mode],
+			Time:      world.TIME_NEW_WORLD,
+		}
+		if info.Name == "" {
+			info.Name = DEFAULT_WORLD_NAME
+		}
+		if strings.TrimSpace(seed.Text) != "" {
+			info.Seed = world.ParseSeed(seed.Text)
+		}
+
+		dir, err := world.CreateSave(savesDir(), info)
+		if err == nil {
+			err = self.enterWorld(dir)
+		}
+		if err != nil {
+			status.Text = "Failed to create the world"
+			fmt.Println("failed to create world:", err)
+		}
+	}
+	name.OnSubmit = func(string) {
+		create()
+	}
+	seed.OnSubmit = name.OnSubmit
+
+	self.showScreen(menuScreen("Create World",
+		ui.NewLabel("World name"),
+		name,
+		ui.NewLabel("Seed"),
+		seed,
+		optionButton("Generator", world.GENERATORS, &generator),
+		optionButton("Game mode", world.GAME_MODES, &mode),
+		status,
+		buttonRow(ui.NewButton("Create", create), ui.NewButton("Cancel", self.showWorldsScreen)),
+	))
+	self.menu.Focus(name)
+}
+
+func (self *App) showRenameWorldScreen(save world.Save) {
+	status := errorLabel()
+
+	name := ui.NewTextField(save.Name())
+	name.MaxLength = WORLD_NAME_LENGTH
+	name.SetText(save.Name())
+	rename := func() {
+		newName := strings.TrimSpace(name.Text)
+		if newName == "" {
+			status.Text = "The name can't be empty"
+			return
+		}
+		err := world.RenameSave(save.Dir, newName)
+		if err != nil {
+			status.Text = "Failed to rename the world"
+			fmt.Println("failed to rename world:", err)
+			return
+		}
+		self.showWorldsScreen()
+	}
+	name.OnSubmit = func(string) {
+		rename()
+	}
+
+	self.showScreen(menuScreen("Rename World",
+		name,
+		status,
+		buttonRow(ui.NewButton("Rename", rename), ui.NewButton("Cancel", self.showWorldsScreen)),
+	))
+	self.menu.Focus(name)
+}
+
+func (self *App) showDeleteWorldScreen(save world.Save) {
+	status := errorLabel()
+
+	remove := func() {
+		err := world.DeleteSave(save.Dir)
+		if err != nil {
+			status.Text = "Failed to delete the world"
+			fmt.Println("failed to delete world:", err)
+			return
+		}
+		self.showWorldsScreen()
+	}
+
+	cancel := ui.NewButton("Cancel", self.showWorldsScreen)
+	self.showScreen(menuScreen("Delete World",
+		ui.NewLabel(fmt.Sprintf("Delete \"%s\"?", save.Name())),
+		ui.NewLabel("It will be lost forever."),
+		status,
+		buttonRow(ui.NewButton("Delete", remove), cancel),
+	))
+	self.menu.Focus(cancel)
+}
+
+// Freezes the world and shows the pause menu.
+func (self *App) pauseGame() {
+	self.paused = true
+	self.showPauseScreen()
+}
+
+// Closes the pause menu and carries on playing.
+func (self *App) resumeGame() {
+	self.paused = false
+	self.hideScreen()
+}
+
+func (self *App) showPauseScreen() {
+	status := ui.NewLabel("")
+	status.Align = ui.ALIGN_CENTER
+
+	save := func() {
+		err := self.world.Save(self.saveDir)
+		if err != nil {
+			status.Text = "Failed to save the world"
+			status.Color = ERROR_TEXT_COLOR
+			fmt.Println("failed to save world:", err)
+			return
+		}
+		status.Text = "World saved"
+		status.Color = mgl32.Vec4{}
+	}
+	quit := func() {
+		self.leaveWorld()
+		self.showTitleScreen()
+	}
+
+	resume := ui.NewButton("Resume", self.resumeGame)
+	self.showScreen(menuScreen("Paused",
+		resume,
+		ui.NewButton("Options", func() {
+			self.showOptionsScreen(self.showPauseScreen)
+		}),
+		ui.NewButton("Save", save),
+		ui.NewButton("Quit to Title", quit),
+		status,
+	))
+	self.menu.Focus(resume)
+}
+
+// Loads the world saved in dir and starts playing it.
+func (self *App) enterWorld(dir string) error {
+	next := world.World{Registry: self.res.blockRegistry, Time: world.TIME_NEW_WORLD}
+	err := next.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	self.world = next
+	self.saveDir = dir
+	err = self.loadWorld(WORLD_SIZE, WORLD_HEIGHT)
+	if err != nil {
+		self.world = world.World{Registry: self.res.blockRegistry, Time: next.Time}
+		return err
+	}
+
+	self.camera = SPAWN_CAMERA
+	self.playing = true
+	self.paused = false
+	self.hideScreen()
+	return nil
+}
+
+// Saves and unloads the world being played, if any.
+func (self *App) leaveWorld() {
+	if !self.playing {
+		return
+	}
+
+	err := self.world.Save(self.saveDir)
+	if err != nil {
+		fmt.Println("failed to save world:", err)
+	}
+
+	self.playing = false
+	self.paused = false
+	self.selection.Visible = false
+	if self.worldRenderer.Arena != nil {
+		self.worldRenderer.Arena.Delete()
+		self.worldRenderer.Arena = nil
+	}
+	// the sky behind the menus carries on from the time of the world
+	self.world = world.World{Registry: self.res.blockRegistry, Time: self.world.Time}
+}
@@ -0,0 +1,293 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+)
+
+// Window sizes offered in the video options.
+var WINDOW_SIZES = [][2]int{
+	{1280, 720},
+	{1600, 900},
+	{1920, 1080},
+	{2560, 1440},
+}
+
+// Slider of a setting: the value is applied with set as it changes.
+func (self *App) settingSlider(text string, min, max, step, value float32, format string, set func(settings *Settings, value float32)) *ui.Slider {
+	slider := ui.NewSlider(text, min, max, value, func(value float32) {
+		self.changeSettings(func(settings *Settings) {
+			set(settings, value)
+		})
+	})
+	slider.Step = step
+	slider.Format = func(value float32) string {
+		return fmt.Sprintf(format, value)
+	}
+	return slider
+}
+
+// Options of the player, opened from the title screen or the pause menu;
+// back shows the screen they were opened from. The settings are saved when
+// leaving.
+func (self *App) showOptionsScreen(back func()) {
+	reopen := func() {
+		self.showOptionsScreen(back)
+	}
+
+	done := ui.NewButton("Done", func() {
+		self.saveSettings()
+		back()
+	})
+	self.showScreen(menuScreen("Options",
+		ui.NewButton("Video...", func() {
+			self.showVideoOptionsScreen(reopen)
+		}),
+		ui.NewButton("Controls...", func() {
+			self.showControlOptionsScreen(reopen)
+		}),
+		ui.NewButton("Gameplay...", func() {
+			self.showGameplayOptionsScreen(reopen)
+		}),
+		ui.NewButton("Reset to Defaults", func() {
+			self.changeSettings(func(settings *Settings) {
+				*settings = DefaultSettings()
+			})
+		}),
+		done,
+	))
+	self.menu.Focus(done)
+}
+
+// Button cycling through the window sizes, including the current one if it
+// is not a usual size.
+func (self *App) windowSizeButton() *ui.Button {
+	video := self.settings.Video
+	sizes := [][2]int{}
+	selected := -1
+	for i, size := range WINDOW_SIZES {
+		if size == [2]int{video.Width, video.Height} {
+			selected = i
+		}
+		sizes = append(sizes, size)
+	}
+	if selected < 0 {
+		sizes = append([][2]int{{video.Width, video.Height}}, sizes...)
+		selected = 0
+	}
+
+	button := ui.NewButton("", nil)
+	update := func() {
+		button.Text = fmt.Sprintf("Window size: %dx%d", sizes[selected][0], sizes[selected][1])
+	}
+	button.OnClick = func() {
+		selected = (selected + 1) % len(sizes)
+		self.changeSettings(func(settings *Settings) {
+			settings.Video.Width, settings.Video.Height = sizes[selected][0], sizes[selected][1]
+		})
+		update()
+	}
+	update()
+	return button
+}
+
+// Button cycling through the display modes.
+func (self *App) displayModeButton() *ui.Button {
+	button := ui.NewButton("", nil)
+	update := func() {
+		button.Text = "Display: " + displayModeLabel(self.settings.Video.DisplayMode)
+	}
+	button.OnClick = func() {
+		selected := 0
+		for i, mode := range DISPLAY_MODES {
+			if mode == self.settings.Video.DisplayMode {
+				selected = i
+			}
+		}
+		self.changeSettings(func(settings *Settings) {
+			settings.Video.DisplayMode = DISPLAY_MODES[(selected+1)%len(DISPLAY_MODES)]
+		})
+		update()
+	}
+	update()
+	return button
+}
+
+// Button cycling through the connected monitors.
+func (self *App) monitorButton() *ui.Button {
+	button := ui.NewButton("", nil)
+	update := func() {
+		button.Text = "Monitor: " + monitorLabel(self.settings.Video.Monitor)
+	}
+	button.OnClick = func() {
+		count := len(glfw.GetMonitors())
+		if count == 0 {
+			return
+		}
+		self.changeSettings(func(settings *Settings) {
+			settings.Video.Monitor = (settings.Video.Monitor + 1) % count
+		})
+		update()
+	}
+	update()
+	return button
+}
+
+// Slider of the frame rate cap, unlimited past its last step.
+func (self *App) frameRateSlider() *ui.Slider {
+	const step = 10
+	unlimited := float32(MAX_FRAME_RATE + step)
+	value := float32(self.settings.Video.FrameRate)
+	if self.settings.Video.FrameRate == UNLIMITED_FRAME_RATE {
+		value = unlimited
+	}
+	slider := self.settingSlider("Frame rate", MIN_FRAME_RATE, unlimited, step, value, "%.0f",
+		func(settings *Settings, value float32) {
+			settings.Video.FrameRate = int(value)
+			if value >= unlimited {
+				settings.Video.FrameRate = UNLIMITED_FRAME_RATE
+			}
+		})
+	slider.Format = func(value float32) string {
+		if value >= unlimited {
+			return "Unlimited"
+		}
+		return fmt.Sprintf("%.0f", value)
+	}
+	return slider
+}
+
+func (self *App) showVideoOptionsScreen(back func()) {
+	video := self.settings.Video
+	widgets := []ui.Widget{
+		self.displayModeButton(),
+		self.monitorButton(),
+		self.windowSizeButton(),
+		ui.NewCheckbox("Vertical sync", video.VSync, func(checked bool) {
+			self.changeSettings(func(settings *Settings) {
+				settings.Video.VSync = checked
+			})
+		}),
+		self.frameRateSlider(),
+		self.settingSlider("Field of view", MIN_FOV, MAX_FOV, 1, float32(video.Fov), "%.0f",
+			func(settings *Settings, value float32) {
+				settings.Video.Fov = float64(value)
+			}),
+		self.settingSlider("View distance", MIN_VIEW_DISTANCE, MAX_VIEW_DISTANCE, 1, float32(video.ViewDistance), "%.0f chunks",
+			func(settings *Settings, value float32) {
+				settings.Video.ViewDistance = int(value)
+			}),
+	}
+	for _, pass := range self.renderer.Post.Passes {
+		name := pass.Name
+		widgets = append(widgets, ui.NewCheckbox("Effect: "+name, pass.Enabled, func(checked bool) {
+			self.changeSettings(func(settings *Settings) {
+				settings.Video.PostEffects[name] = checked
+			})
+		}))
+	}
+	widgets = append(widgets, ui.NewButton("Back", back))
+
+	self.showScreen(menuScreen("Video", widgets...))
+}
+
+func (self *App) showControlOptionsScreen(back func()) {
+	controls := self.settings.Controls
+	reopen := func() {
+		self.showControlOptionsScreen(back)
+	}
+	self.showScreen(menuScreen("Controls",
+		ui.NewButton("Key Bindings...", func() {
+			self.showBindingsScreen(reopen)
+		}),
+		self.settingSlider("Mouse sensitivity", MIN_SENSITIVITY, MAX_SENSITIVITY, 0.1, float32(controls.MouseSensitivity), "%.1f",
+			func(settings *Settings, value float32) {
+				settings.Controls.MouseSensitivity = float64(value)
+			}),
+		ui.NewCheckbox("Invert mouse", controls.InvertMouse, func(checked bool) {
+			self.changeSettings(func(settings *Settings) {
+				settings.Controls.InvertMouse = checked
+			})
+		}),
+		ui.NewButton("Back", back),
+	))
+}
+
+func (self *App) showGameplayOptionsScreen(back func()) {
+	gameplay := self.settings.Gameplay
+	self.showScreen(menuScreen("Gameplay",
+		self.settingSlider("Fly speed", MIN_FLY_SPEED, MAX_FLY_SPEED, 0.5, float32(gameplay.FlySpeed), "%.1f",
+			func(settings *Settings, value float32) {
+				settings.Gameplay.FlySpeed = float64(value)
+			}),
+		self.settingSlider("Sprint multiplier", MIN_SPRINT_MULTIPLIER, MAX_SPRINT_MULTIPLIER, 0.5, float32(gameplay.SprintMultiplier), "%.1f",
+			func(settings *Settings, value float32) {
+				settings.Gameplay.SprintMultiplier = float64(value)
+			}),
+		ui.NewButton("Back", back),
+	))
+}
+
+// Key cancelling the change of a binding instead of being bound.
+var CANCEL_REBIND = input.Key("Escape")
+
+// Lists the button of every action; the selected one is changed to the next
+// button pressed, or reset to its default.
+func (self *App) showBindingsScreen(back func()) {
+	status := ui.NewLabel("")
+	status.Align = ui.ALIGN_CENTER
+
+	list := ui.NewList(make([]string, len(ACTIONS)))
+	list.Rows = 10
+	list.Selected = 0
+	update := func() {
+		// rows are rewritten in place so the list keeps its scrolling
+		for i, info := range ACTIONS {
+			button, _ := input.ParseButton(self.settings.Controls.Bindings[string(info.action)])
+			list.Items[i] = info.label + ": " + buttonLabel(button)
+		}
+	}
+	update()
+
+	bind := func(info actionInfo, button input.Button) {
+		self.changeSettings(func(settings *Settings) {
+			settings.Controls.Bindings[string(info.action)] = button.String()
+		})
+		status.Text = ""
+		update()
+	}
+	change := func() {
+		if list.Selected < 0 || list.Selected >= len(ACTIONS) {
+			return
+		}
+		info := ACTIONS[list.Selected]
+		status.Text = "Press a button for " + info.label + ", Escape to cancel"
+		self.rebind = func(button input.Button) {
+			if button == CANCEL_REBIND {
+				status.Text = ""
+				return
+			}
+			bind(info, button)
+		}
+	}
+	list.OnActivate = func(index int) {
+		change()
+	}
+	reset := func() {
+		if list.Selected >= 0 && list.Selected < len(ACTIONS) {
+			info := ACTIONS[list.Selected]
+			bind(info, info.button)
+		}
+	}
+
+	self.showScreen(menuScreen("Key Bindings",
+		list,
+		status,
+		buttonRow(ui.NewButton("Change", change), ui.NewButton("Reset", reset)),
+		ui.NewButton("Back", back),
+	))
+	self.menu.Focus(list)
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/hexagon-0/voxel-game/assets"
+	"github.com/hexagon-0/voxel-game/internal/client/render"
+	"github.com/hexagon-0/voxel-game/internal/common/asset"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Everything loaded through the asset stack. It is replaced as a whole when
+// resource packs are reloaded, so a broken pack never leaves the game with a
+// mix of old and new assets.
+type resources struct {
+	assets        *asset.Manager
+	packs         []*asset.Pack
+	blockRegistry *world.BlockRegistry
+	blockTextures render.BlockTextures
+	font          *render.Font
+	shaders       *render.ShaderManager
+	chunkShader   *render.ManagedProgram
+	selectShader  *render.ManagedProgram
+	uiShader      *render.ManagedProgram
+	textShader    *render.ManagedProgram
+	skyShader     *render.ManagedProgram
+	shadowShader  *render.ManagedProgram
+	// post-processing programs, by render.POST_SHADERS name
+	postShaders map[string]*render.ManagedProgram
+}
+
+// Dev mode is enabled by setting this environment variable to any non-empty
+// value. Shaders are then reloaded as soon as their files change on disk.
+const DEV_MODE_ENV = "VOXEL_GAME_DEV"
+
+func devMode() bool {
+	return os.Getenv(DEV_MODE_ENV) != ""
+}
+
+// Directory scanned for resource packs: <user config dir>/voxel-game/resourcepacks.
+func resourcePacksDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "voxel-game", "resourcepacks")
+}
+
+// Path of a file in the configuration directory of the game.
+func configPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "voxel-game", name)
+}
+
+// Directory holding a directory per saved world: <user config
+// dir>/voxel-game/saves.
+func savesDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "voxel-game", "saves")
+}
+
+// Builds the asset stack: resource packs by priority unless usePacks is
+// false, then the local assets directory if present, then the embedded
+// defaults.
+func newAssetManager(usePacks bool) (*asset.Manager, []*asset.Pack) {
+	manager := asset.NewManager(asset.Layer{Name: "embedded", FS: assets.FS})
+	if info, err := os.Stat(ASSETS_DIR); err == nil && info.IsDir() {
+		manager.Push(asset.Layer{Name: ASSETS_DIR, FS: os.DirFS(ASSETS_DIR)})
+	}
+
+	packsDir := resourcePacksDir()
+	if !usePacks || packsDir == "" {
+		return manager, nil
+	}
+
+	packs, errs := asset.OpenPacks(packsDir)
+	for _, err := range errs {
+		fmt.Println("skipping resource pack:", err)
+	}
+	for i := len(packs) - 1; i >= 0; i-- {
+		manager.Push(packs[i].Layer())
+	}
+
+	return manager, packs
+}
+
+// Loads the resources from the asset stack, see newAssetManager.
+func loadResources(usePacks bool) (*resources, error) {
+	res := resources{}
+	res.assets, res.packs = newAssetManager(usePacks)
+
+	ok := false
+	defer func() {
+		if !ok {
+			res.Delete()
+		}
+	}()
+
+	blockDefs, err := res.assets.ReadFile("blocks.json")
+	if err != nil {
+		return nil, err
+	}
+	res.blockRegistry, err = world.ParseBlockRegistry(blockDefs)
+	if err != nil {
+		return nil, err
+	}
+
+	res.blockTextures, err = render.NewBlockTextures(res.blockRegistry, func(name string) (*image.RGBA, error) {
+		return scene.LoadImage(res.assets, "textures/"+name+".png")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res.font, err = render.LoadFont(res.assets, FONT_NAME)
+	if err != nil {
+		return nil, err
+	}
+
+	res.shaders = render.NewShaderManager(res.assets, devMode())
+
+	res.chunkShader, err = res.shaders.Load("shaders/chunk.vert", "shaders/chunk.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.selectShader, err = res.shaders.Load("shaders/selection.vert", "shaders/selection.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.uiShader, err = res.shaders.Load("shaders/ui.vert", "shaders/ui.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.textShader, err = res.shaders.Load("shaders/text.vert", "shaders/text.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.skyShader, err = res.shaders.Load("shaders/sky.vert", "shaders/sky.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.shadowShader, err = res.shaders.Load("shaders/shadow.vert", "shaders/shadow.frag")
+	if err != nil {
+		return nil, err
+	}
+
+	res.postShaders = make(map[string]*render.ManagedProgram)
+	for _, name := range render.POST_SHADERS {
+		res.postShaders[name], err = res.shaders.Load(render.POST_VERTEX_SHADER, render.PostShaderPath(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ok = true
+	return &res, nil
+}
+
+// Frees the GL objects and open packs held by the resources.
+func (self *resources) Delete() {
+	if self.blockTextures.Texture != 0 {
+		gl.DeleteTextures(1, &self.blockTextures.Texture)
+	}
+	if self.font != nil {
+		self.font.Delete()
+	}
+	if self.shaders != nil {
+		self.shaders.Delete()
+	}
+	if self.assets != nil {
+		self.assets.Close()
+	}
+}
@@ -0,0 +1,314 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/client/render"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Name of the settings file in the user configuration directory.
+const SETTINGS_FILE = "settings.json"
+
+// Limits of the settings; values outside them are clamped when loaded.
+const (
+	MIN_WINDOW_WIDTH  = 640
+	MIN_WINDOW_HEIGHT = 360
+	MAX_WINDOW_SIZE   = 16384
+
+	MIN_FOV = 30
+	MAX_FOV = 120
+
+	MIN_FRAME_RATE = 20
+	MAX_FRAME_RATE = 240
+
+	// monitors are listed from 0, the primary one
+	MAX_MONITOR = 15
+
+	MIN_VIEW_DISTANCE = 2
+	MAX_VIEW_DISTANCE = 16
+
+	MIN_SENSITIVITY = 0.1
+	MAX_SENSITIVITY = 4
+
+	MIN_FLY_SPEED = 0.5
+	MAX_FLY_SPEED = 20
+
+	MIN_SPRINT_MULTIPLIER = 1
+	MAX_SPRINT_MULTIPLIER = 10
+)
+
+type VideoSettings struct {
+	// DISPLAY_WINDOWED, DISPLAY_FULLSCREEN or DISPLAY_BORDERLESS
+	DisplayMode string `json:"displayMode"`
+	// index of the monitor used in fullscreen and borderless modes, and to
+	// centre the window on
+	Monitor int `json:"monitor"`
+	// window size in screen coordinates, in windowed mode
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// horizontal field of view, in degrees
+	Fov float64 `json:"fov"`
+	// frames per second at most, or UNLIMITED_FRAME_RATE
+	FrameRate int  `json:"frameRate"`
+	VSync     bool `json:"vsync"`
+	// how far the world is visible before it fades into the fog, in chunks
+	ViewDistance int `json:"viewDistance"`
+	// post-processing passes by name; passes left out keep their default
+	PostEffects map[string]bool `json:"postEffects"`
+}
+
+type ControlSettings struct {
+	// multiplier of the mouse look speed
+	MouseSensitivity float64 `json:"mouseSensitivity"`
+	InvertMouse      bool    `json:"invertMouse"`
+	// button of every action, by action name, like "move_forward": "key:W"
+	Bindings map[string]string `json:"bindings"`
+}
+
+type GameplaySettings struct {
+	// flying speed, in blocks per second
+	FlySpeed float64 `json:"flySpeed"`
+	// speed multiplier while sprinting
+	SprintMultiplier float64 `json:"sprintMultiplier"`
+}
+
+// Settings are the options of the player, saved between runs.
+type Settings struct {
+	Video    VideoSettings    `json:"video"`
+	Controls ControlSettings  `json:"controls"`
+	Gameplay GameplaySettings `json:"gameplay"`
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		Video: VideoSettings{
+			DisplayMode:  DISPLAY_WINDOWED,
+			Width:        WIDTH,
+			Height:       HEIGHT,
+			Fov:          90,
+			FrameRate:    FPS,
+			VSync:        true,
+			ViewDistance: VIEW_DISTANCE,
+			PostEffects:  defaultPostEffects(),
+		},
+		Controls: ControlSettings{
+			MouseSensitivity: 1,
+			Bindings:         defaultBindings(),
+		},
+		Gameplay: GameplaySettings{
+			FlySpeed:         2.5,
+			SprintMultiplier: 4,
+		},
+	}
+}
+
+// Whether each post-processing pass starts enabled, by pass name.
+func defaultPostEffects() map[string]bool {
+	passes := render.DefaultPostPasses()
+	effects := make(map[string]bool, len(passes))
+	for _, pass := range passes {
+		effects[pass.Name] = pass.Enabled
+	}
+	return effects
+}
+
+// Path of the settings file: <user config dir>/voxel-game/settings.json.
+func settingsPath() string {
+	return configPath(SETTINGS_FILE)
+}
+
+// Settings found out of their limits while validating.
+type settingProblems []string
+
+func (self *settingProblems) clampInt(name string, value *int, min, max int) {
+	if *value >= min && *value <= max {
+		return
+	}
+	*self = append(*self, fmt.Sprintf("`%s` must be between %d and %d, got %d", name, min, max, *value))
+	if *value < min {
+		*value = min
+	} else {
+		*value = max
+	}
+}
+
+func (self *settingProblems) clampFloat(name string, value *float64, min, max float64) {
+	if *value >= min && *value <= max {
+		return
+	}
+	*self = append(*self, fmt.Sprintf("`%s` must be between %g and %g, got %g", name, min, max, *value))
+	if *value < min {
+		*value = min
+	} else {
+		*value = max
+	}
+}
+
+// Replaces the bindings of unknown buttons by the defaults, and drops those of
+// unknown actions.
+func (self *settingProblems) checkBindings(bindings *map[string]string) {
+	defaults := defaultBindings()
+	if *bindings == nil {
+		*bindings = defaults
+		return
+	}
+
+	actions := make([]string, 0, len(*bindings))
+	for action := range *bindings {
+		actions = append(actions, action)
+	}
+	// sorted so the problems are listed in the same order every time
+	sort.Strings(actions)
+	for _, action := range actions {
+		binding := (*bindings)[action]
+		if _, ok := defaults[action]; !ok {
+			*self = append(*self, fmt.Sprintf("unknown action `%s`", action))
+			delete(*bindings, action)
+			continue
+		}
+		button, err := input.ParseButton(binding)
+		if err == nil && !knownButton(button) {
+			err = fmt.Errorf("unknown button `%s`", binding)
+		}
+		if err != nil {
+			*self = append(*self, fmt.Sprintf("binding of `%s`: %s", action, err))
+			(*bindings)[action] = defaults[action]
+		}
+	}
+	for action, binding := range defaults {
+		if _, ok := (*bindings)[action]; !ok {
+			(*bindings)[action] = binding
+		}
+	}
+}
+
+// Validate clamps every setting into its limits, and resets invalid key
+// bindings, returning an error listing the settings that were invalid.
+func (self *Settings) Validate() error {
+	problems := settingProblems{}
+	if !isDisplayMode(self.Video.DisplayMode) {
+		problems = append(problems, fmt.Sprintf("`video.displayMode` must be one of %s, got `%s`",
+			strings.Join(DISPLAY_MODES, ", "), self.Video.DisplayMode))
+		self.Video.DisplayMode = DISPLAY_WINDOWED
+	}
+	problems.clampInt("video.monitor", &self.Video.Monitor, 0, MAX_MONITOR)
+	problems.clampInt("video.width", &self.Video.Width, MIN_WINDOW_WIDTH, MAX_WINDOW_SIZE)
+	problems.clampInt("video.height", &self.Video.Height, MIN_WINDOW_HEIGHT, MAX_WINDOW_SIZE)
+	problems.clampFloat("video.fov", &self.Video.Fov, MIN_FOV, MAX_FOV)
+	if self.Video.FrameRate != UNLIMITED_FRAME_RATE {
+		problems.clampInt("video.frameRate", &self.Video.FrameRate, MIN_FRAME_RATE, MAX_FRAME_RATE)
+	}
+	problems.clampInt("video.viewDistance", &self.Video.ViewDistance, MIN_VIEW_DISTANCE, MAX_VIEW_DISTANCE)
+	problems.clampFloat("controls.mouseSensitivity", &self.Controls.MouseSensitivity, MIN_SENSITIVITY, MAX_SENSITIVITY)
+	problems.clampFloat("gameplay.flySpeed", &self.Gameplay.FlySpeed, MIN_FLY_SPEED, MAX_FLY_SPEED)
+	problems.clampFloat("gameplay.sprintMultiplier", &self.Gameplay.SprintMultiplier, MIN_SPRINT_MULTIPLIER, MAX_SPRINT_MULTIPLIER)
+	if self.Video.PostEffects == nil {
+		self.Video.PostEffects = defaultPostEffects()
+	}
+	problems.checkBindings(&self.Controls.Bindings)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid settings: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LoadSettings reads the settings file at path over the defaults. A missing
+// file gives the defaults; invalid values are replaced and reported in the
+// error, with the usable settings still returned.
+func LoadSettings(path string) (Settings, error) {
+	settings := DefaultSettings()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return DefaultSettings(), fmt.Errorf("failed to parse `%s`: %w", path, err)
+	}
+	return settings, settings.Validate()
+}
+
+// Save writes the settings to path, creating its directory if needed.
+func (self Settings) Save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(self, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Vertical field of view in radians for the horizontal one of the settings.
+func (self Settings) fovy(aspect float64) float64 {
+	fovx := self.Video.Fov * math.Pi / 180
+	return 2 * math.Atan(math.Tan(fovx/2)/aspect)
+}
+
+// Applies the settings that take effect while the game runs.
+func (self *App) applySettings() {
+	for _, info := range ACTIONS {
+		button, err := input.ParseButton(self.settings.Controls.Bindings[string(info.action)])
+		if err != nil {
+			button = info.button
+		}
+		buttons := []input.Button{button}
+		// the gamepad mapping is validated when loaded
+		if gamepad, err := input.ParseButton(self.padMapping.Bindings[string(info.action)]); err == nil {
+			buttons = append(buttons, gamepad)
+		}
+		self.actions.Bind(info.action, buttons...)
+	}
+	self.applyDisplay()
+	self.worldRenderer.FogDistance = float32(self.settings.Video.ViewDistance * world.CHUNK_SIZE)
+	for _, pass := range self.renderer.Post.Passes {
+		if enabled, ok := self.settings.Video.PostEffects[pass.Name]; ok {
+			pass.Enabled = enabled
+		}
+	}
+}
+
+// Changes the settings with change and applies them; they are saved when the
+// options are closed or the game exits.
+func (self *App) changeSettings(change func(settings *Settings)) {
+	change(&self.settings)
+	self.settings.Validate()
+	self.settingsDirty = true
+	self.applySettings()
+}
+
+// Writes the settings if they changed since they were last saved.
+func (self *App) saveSettings() {
+	if !self.settingsDirty {
+		return
+	}
+	err := self.settings.Save(settingsPath())
+	if err != nil {
+		fmt.Println("failed to save settings:", err)
+		return
+	}
+	self.settingsDirty = false
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexagon-0/voxel-game/internal/client/render"
+)
+
+func TestFovy(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Video.Fov = 90
+	cases := []struct {
+		aspect, want float64
+	}{
+		{1, math.Pi / 2},
+		{2, 2 * math.Atan(0.5)},
+		{0.5, 2 * math.Atan(2)},
+	}
+	for _, c := range cases {
+		if got := settings.fovy(c.aspect); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("fovy(%g) = %g for a 90 degree horizontal field of view, want %g", c.aspect, got, c.want)
+		}
+	}
+}
+
+func TestPostEffectDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SETTINGS_FILE)
+	err := os.WriteFile(path, []byte(`{"video": {"postEffects": {"fxaa": false}}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := DefaultSettings()
+	for _, pass := range render.DefaultPostPasses() {
+		if enabled, ok := defaults.Video.PostEffects[pass.Name]; !ok || enabled != pass.Enabled {
+			t.Errorf("pass `%s` is %t in the default settings, want %t", pass.Name, enabled, pass.Enabled)
+		}
+		want := pass.Enabled && pass.Name != "fxaa"
+		if enabled := settings.Video.PostEffects[pass.Name]; enabled != want {
+			t.Errorf("pass `%s` loaded as %t, want %t", pass.Name, enabled, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/render"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/space"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Snapshot describes a still frame rendered without a visible window, see
+// RenderSnapshot.
+type Snapshot struct {
+	Width  int
+	Height int
+	// world generated for the snapshot, in chunks
+	WorldSize   int
+	WorldHeight int
+	// time of day, in ticks since midnight
+	Time   int64
+	Camera space.Transform
+	// vertical field of view, in radians
+	Fovy float32
+}
+
+// RenderSnapshot renders a generated world from a camera pose into an
+// off-screen framebuffer and reads it back. The GL context belongs to an
+// invisible window, so it needs a display but no GPU: Mesa's llvmpipe under
+// a virtual X server (xvfb-run) is enough, which makes this usable for
+// comparing rendering changes against golden images in CI.
+func RenderSnapshot(snapshot Snapshot) (*image.RGBA, error) {
+	err := glfw.Init()
+	if err != nil {
+		return nil, err
+	}
+	defer glfw.Terminate()
+
+	glfw.WindowHint(glfw.Visible, glfw.False)
+	glfw.WindowHint(glfw.ContextVersionMajor, 4)
+	glfw.WindowHint(glfw.ContextVersionMinor, 1)
+	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
+
+	window, err := glfw.CreateWindow(1, 1, "snapshot", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create an OpenGL context: %w", err)
+	}
+	defer window.Destroy()
+	window.MakeContextCurrent()
+
+	err = gl.Init()
+	if err != nil {
+		return nil, err
+	}
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.CULL_FACE)
+
+	width, height := int32(snapshot.Width), int32(snapshot.Height)
+
+	var self App
+	self.window = window
+	// the settings of the player would make snapshots differ too
+	self.settings = DefaultSettings()
+	err = self.initRendering(width, height)
+	if err != nil {
+		return nil, err
+	}
+	defer self.deleteRendering()
+
+	output, err := render.NewFramebuffer(width, height, gl.RGBA8, true)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Delete()
+	self.renderer.Output = output
+
+	// resource packs of the user would make snapshots differ between machines
+	res, err := loadResources(false)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Delete()
+	self.useResources(res)
+
+	self.world.Time = snapshot.Time
+	err = self.loadWorld(snapshot.WorldSize, snapshot.WorldHeight)
+	if err != nil {
+		return nil, err
+	}
+	defer self.worldRenderer.Arena.Delete()
+
+	camera := snapshot.Camera
+	aspect := float32(width) / float32(height)
+	frame := render.FrameContext{
+		Projection:     perspective(snapshot.Fovy, aspect),
+		View:           camera.ViewMatrix(),
+		CameraPosition: camera.Origin,
+		Fovy:           snapshot.Fovy,
+		Aspect:         aspect,
+		Width:          width,
+		Height:         height,
+		Sky:            scene.NewSkyState(self.world.TimeOfDay()),
+	}
+	cameraBlock, _ := self.world.BlockAt(
+		int(math.Floor(float64(camera.Origin[0]))),
+		int(math.Floor(float64(camera.Origin[1]))),
+		int(math.Floor(float64(camera.Origin[2]))),
+	)
+	frame.Underwater = self.world.Registry.IsLiquid(cameraBlock)
+
+	err = self.renderer.Render(&frame)
+	if err != nil {
+		return nil, err
+	}
+	gl.Finish()
+
+	return output.ReadPixels(), nil
+}
+
+// DefaultSnapshot looks at the middle of the default world from above one of
+// its corners, at noon.
+func DefaultSnapshot() Snapshot {
+	center := float32(WORLD_SIZE*world.CHUNK_SIZE) / 2
+	return Snapshot{
+		Width:       800,
+		Height:      600,
+		WorldSize:   WORLD_SIZE,
+		WorldHeight: WORLD_HEIGHT,
+		Time:        world.TIME_NOON,
+		Camera: space.LookAt(
+			mgl32.Vec3{0, WORLD_HEIGHT*world.CHUNK_SIZE + 8, 0},
+			mgl32.Vec3{center, WORLD_HEIGHT * world.CHUNK_SIZE / 2, center},
+			scene.WorldUp,
+		),
+		Fovy: math.Pi / 3,
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+)
+
+// Longest delay between the presses of a double click, in seconds.
+const DOUBLE_CLICK_TIME = 0.4
+
+// Keys the widgets react to.
+var uiKeys = map[glfw.Key]ui.Key{
+	glfw.KeyTab:       ui.KEY_TAB,
+	glfw.KeyEnter:     ui.KEY_ENTER,
+	glfw.KeyKPEnter:   ui.KEY_ENTER,
+	glfw.KeyEscape:    ui.KEY_ESCAPE,
+	glfw.KeySpace:     ui.KEY_SPACE,
+	glfw.KeyBackspace: ui.KEY_BACKSPACE,
+	glfw.KeyDelete:    ui.KEY_DELETE,
+	glfw.KeyLeft:      ui.KEY_LEFT,
+	glfw.KeyRight:     ui.KEY_RIGHT,
+	glfw.KeyUp:        ui.KEY_UP,
+	glfw.KeyDown:      ui.KEY_DOWN,
+	glfw.KeyHome:      ui.KEY_HOME,
+	glfw.KeyEnd:       ui.KEY_END,
+}
+
+// Converts a cursor position from window coordinates to framebuffer pixels,
+// which differ on high DPI screens.
+func (self *App) cursorPixels(x, y float64) (float32, float32) {
+	width, height := self.window.GetSize()
+	fbWidth, fbHeight := self.window.GetFramebufferSize()
+	if width == 0 || height == 0 {
+		return float32(x), float32(y)
+	}
+	return float32(x * float64(fbWidth) / float64(width)), float32(y * float64(fbHeight) / float64(height))
+}
+
+// Sends an input event to the interface, if one is shown, and returns
+// whether a widget consumed it.
+func (self *App) uiEvent(event ui.Event) bool {
+	if self.ui.Root == nil {
+		return false
+	}
+	return self.ui.Root.HandleEvent(event)
+}
+
+// Hands a pressed button to the key binding being changed, if any, and
+// returns whether it took it.
+func (self *App) captureBinding(button input.Button) bool {
+	if self.rebind == nil {
+		return false
+	}
+	rebind := self.rebind
+	self.rebind = nil
+	// the actions must not see the press either
+	self.latchInput = true
+	rebind(button)
+	return true
+}
+
+// Installs the window callbacks turning GLFW input into interface events,
+// or into the button of a key binding being changed.
+func (self *App) setupUiInput() {
+	lastClick := -1.0
+	// mouse buttons whose press went to a key binding, so their release
+	// doesn't click the interface
+	swallowed := map[glfw.MouseButton]bool{}
+
+	self.window.SetCursorPosCallback(func(window *glfw.Window, x, y float64) {
+		// the cursor moves by itself when captured or released, so only
+		// movement over the menus counts as using the mouse
+		if self.ui.Root != nil {
+			self.setUsingGamepad(false)
+		}
+		px, py := self.cursorPixels(x, y)
+		self.uiEvent(ui.Event{Kind: ui.EVENT_MOUSE_MOVE, X: px, Y: py})
+	})
+
+	self.window.SetMouseButtonCallback(func(window *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		self.setUsingGamepad(false)
+		if action == glfw.Press {
+			if bound, ok := mouseButton(button); ok && self.captureBinding(bound) {
+				swallowed[button] = true
+				return
+			}
+		} else if swallowed[button] {
+			delete(swallowed, button)
+			return
+		}
+		if button != glfw.MouseButtonLeft {
+			return
+		}
+		px, py := self.cursorPixels(window.GetCursorPos())
+		event := ui.Event{Kind: ui.EVENT_MOUSE_UP, X: px, Y: py}
+		if action == glfw.Press {
+			now := glfw.GetTime()
+			event.Kind = ui.EVENT_MOUSE_DOWN
+			event.Clicks = 1
+			if lastClick >= 0 && now-lastClick < DOUBLE_CLICK_TIME {
+				event.Clicks = 2
+				lastClick = -1
+			} else {
+				lastClick = now
+			}
+		}
+		self.uiEvent(event)
+	})
+
+	self.window.SetScrollCallback(func(window *glfw.Window, xoff, yoff float64) {
+		self.setUsingGamepad(false)
+		if yoff != 0 && self.captureBinding(input.Scroll(scrollName(yoff))) {
+			return
+		}
+		self.source.addScroll(yoff)
+		px, py := self.cursorPixels(window.GetCursorPos())
+		self.uiEvent(ui.Event{Kind: ui.EVENT_SCROLL, X: px, Y: py, Scroll: float32(yoff)})
+	})
+
+	self.window.SetKeyCallback(func(window *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		self.setUsingGamepad(false)
+		if action == glfw.Press {
+			if bound, ok := keyButton(key); ok && self.captureBinding(bound) {
+				return
+			}
+		}
+		uiKey, ok := uiKeys[key]
+		if !ok || action == glfw.Release {
+			return
+		}
+		self.uiEvent(ui.Event{Kind: ui.EVENT_KEY, Key: uiKey, Shift: mods&glfw.ModShift != 0})
+	})
+
+	self.window.SetCharCallback(func(window *glfw.Window, char rune) {
+		self.uiEvent(ui.Event{Kind: ui.EVENT_CHAR, Char: char})
+	})
+}
@@ -0,0 +1,72 @@
+// Package game holds the gameplay rules that turn the actions of the player
+// into changes to the camera and the world. It depends on neither GLFW nor
+// OpenGL, so it can be driven by an input.FakeSource in tests.
+package game
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+)
+
+// Actions of the player, bound to buttons in the settings.
+const (
+	ACTION_MOVE_FORWARD  input.Action = "move_forward"
+	ACTION_MOVE_BACK     input.Action = "move_back"
+	ACTION_MOVE_LEFT     input.Action = "move_left"
+	ACTION_MOVE_RIGHT    input.Action = "move_right"
+	ACTION_JUMP          input.Action = "jump"
+	ACTION_SNEAK         input.Action = "sneak"
+	ACTION_SPRINT        input.Action = "sprint"
+	ACTION_BREAK         input.Action = "break"
+	ACTION_PLACE         input.Action = "place"
+	ACTION_NEXT_BLOCK    input.Action = "next_block"
+	ACTION_PREV_BLOCK    input.Action = "prev_block"
+	ACTION_PAUSE         input.Action = "pause"
+	ACTION_TOGGLE_DEBUG  input.Action = "toggle_debug"
+	ACTION_RELOAD_ASSETS input.Action = "reload_assets"
+)
+
+// MoveDirection returns the direction the held movement actions fly the
+// camera in, not normalised vertically so flying up while moving is as fast
+// as moving.
+func MoveDirection(actions *input.Map, front, right mgl32.Vec3) mgl32.Vec3 {
+	direction := mgl32.Vec3{}
+	if actions.Held(ACTION_MOVE_FORWARD) {
+		direction = direction.Add(front)
+	}
+	if actions.Held(ACTION_MOVE_BACK) {
+		direction = direction.Sub(front)
+	}
+	if actions.Held(ACTION_MOVE_RIGHT) {
+		direction = direction.Add(right)
+	}
+	if actions.Held(ACTION_MOVE_LEFT) {
+		direction = direction.Sub(right)
+	}
+
+	direction[1] = 0.0
+	if direction.LenSqr() != 0.0 {
+		direction = direction.Normalize()
+	}
+
+	if actions.Held(ACTION_JUMP) {
+		direction = direction.Add(scene.WorldUp)
+	}
+	if actions.Held(ACTION_SNEAK) {
+		direction = direction.Sub(scene.WorldUp)
+	}
+	return direction
+}
+
+// StickDirection returns the horizontal direction the left stick of the
+// gamepad moves the camera in, as long as the stick is tilted.
+func StickDirection(gamepad *input.GamepadSource, front, right mgl32.Vec3) mgl32.Vec3 {
+	x, y := gamepad.Stick(false)
+	flat := mgl32.Vec3{front[0], 0, front[2]}
+	if flat.LenSqr() != 0.0 {
+		flat = flat.Normalize()
+	}
+	// the stick points down for positive y
+	return flat.Mul(-y).Add(right.Mul(x))
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Target is the block the camera is looking at, found by the raycast of the
+// update.
+type Target struct {
+	Hit   bool
+	Block [3]int
+	Id    world.BlockId
+	// face of the block the ray hit, see world.VoxelRaycast
+	Face int
+}
+
+// Player is the state of the player kept across updates, apart from the
+// camera.
+type Player struct {
+	// index of the block placed among the registry definitions, wrapped
+	// around when used
+	HeldBlock int
+}
+
+// HeldBlockDef returns the block placed by the place action, nil if there
+// are no blocks.
+func (self *Player) HeldBlockDef(registry *world.BlockRegistry) *world.BlockDef {
+	if registry == nil {
+		return nil
+	}
+	defs := registry.Defs()
+	if len(defs) == 0 {
+		return nil
+	}
+	self.HeldBlock = (self.HeldBlock%len(defs) + len(defs)) % len(defs)
+	return defs[self.HeldBlock]
+}
+
+// UseBlocks breaks or places blocks at the target of the crosshair, and
+// picks the block to place, following the actions of the update. Blocks are
+// never placed inside the camera at eye.
+func (self *Player) UseBlocks(actions *input.Map, w *world.World, eye mgl32.Vec3, target Target) {
+	self.HeldBlock += actions.Presses(ACTION_NEXT_BLOCK) - actions.Presses(ACTION_PREV_BLOCK)
+	if !target.Hit {
+		return
+	}
+
+	x, y, z := target.Block[0], target.Block[1], target.Block[2]
+	if actions.Pressed(ACTION_BREAK) {
+		w.SetBlock(x, y, z, 0)
+		return
+	}
+
+	held := self.HeldBlockDef(w.Registry)
+	if held == nil || target.Face < 0 || !actions.Pressed(ACTION_PLACE) {
+		return
+	}
+	normal := world.FaceNormal(target.Face)
+	x, y, z = x+normal[0], y+normal[1], z+normal[2]
+
+	if x == int(math.Floor(float64(eye[0]))) && y == int(math.Floor(float64(eye[1]))) && z == int(math.Floor(float64(eye[2]))) {
+		return
+	}
+	if id, err := w.BlockAt(x, y, z); err == nil && id == 0 {
+		w.SetBlock(x, y, z, held.Id)
+	}
+}
@@ -0,0 +1,176 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/input"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+const TEST_BLOCKS = `[
+	{"id": 1, "name": "dirt", "textures": {"all": "dirt"}},
+	{"id": 2, "name": "stone", "textures": {"all": "stone"}},
+	{"id": 3, "name": "glass", "textures": {"all": "glass"}}
+]`
+
+var TEST_BINDINGS = map[input.Action][]input.Button{
+	ACTION_MOVE_FORWARD: {input.Key("W")},
+	ACTION_MOVE_BACK:    {input.Key("S")},
+	ACTION_MOVE_LEFT:    {input.Key("A")},
+	ACTION_MOVE_RIGHT:   {input.Key("D")},
+	ACTION_JUMP:         {input.Key("Space")},
+	ACTION_SNEAK:        {input.Key("LeftShift")},
+	ACTION_BREAK:        {input.Mouse("left")},
+	ACTION_PLACE:        {input.Mouse("right")},
+	ACTION_NEXT_BLOCK:   {input.Scroll(input.SCROLL_DOWN)},
+	ACTION_PREV_BLOCK:   {input.Scroll(input.SCROLL_UP)},
+}
+
+// A flat world of a single chunk, dirt up to world.FLAT_HEIGHT.
+func newTestWorld(t *testing.T) *world.World {
+	t.Helper()
+	registry, err := world.ParseBlockRegistry([]byte(TEST_BLOCKS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &world.World{Chunks: map[[3]int]*world.Chunk{}, Registry: registry, Generator: world.GENERATOR_FLAT}
+	err = w.LoadChunk(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// Presses a button for one update, then releases it.
+func click(source *input.FakeSource, actions *input.Map, button input.Button) {
+	source.Press(button)
+	actions.Update(source)
+	source.Release(button)
+}
+
+func TestMoveDirection(t *testing.T) {
+	front := mgl32.Vec3{1, 0, 0}
+	up := mgl32.Vec3{1, 1, 0}.Normalize()
+	right := mgl32.Vec3{0, 0, 1}
+	cases := []struct {
+		keys  []string
+		front mgl32.Vec3
+		want  mgl32.Vec3
+	}{
+		{nil, front, mgl32.Vec3{}},
+		{[]string{"W"}, front, mgl32.Vec3{1, 0, 0}},
+		// looking up still moves horizontally, at full speed
+		{[]string{"W"}, up, mgl32.Vec3{1, 0, 0}},
+		{[]string{"W", "S"}, front, mgl32.Vec3{}},
+		{[]string{"W", "D"}, front, mgl32.Vec3{1, 0, 1}.Normalize()},
+		{[]string{"A"}, front, mgl32.Vec3{0, 0, -1}},
+		// flying up adds to moving instead of slowing it down
+		{[]string{"W", "Space"}, front, mgl32.Vec3{1, 1, 0}},
+		{[]string{"LeftShift"}, front, mgl32.Vec3{0, -1, 0}},
+	}
+	for _, c := range cases {
+		source := input.NewFakeSource()
+		actions := input.NewMap(TEST_BINDINGS)
+		for _, key := range c.keys {
+			source.Press(input.Key(key))
+		}
+		actions.Update(source)
+
+		if got := MoveDirection(actions, c.front, right); !got.ApproxEqualThreshold(c.want, 1e-5) {
+			t.Errorf("keys %v looking towards %v move towards %v, want %v", c.keys, c.front, got, c.want)
+		}
+	}
+}
+
+func TestBreakAndPlace(t *testing.T) {
+	w := newTestWorld(t)
+	source := input.NewFakeSource()
+	actions := input.NewMap(TEST_BINDINGS)
+	player := Player{}
+	eye := mgl32.Vec3{4.5, world.FLAT_HEIGHT + 0.5, 4.5}
+	top := world.FLAT_HEIGHT - 1
+	target := Target{Hit: true, Block: [3]int{2, top, 2}, Id: 1, Face: world.FACE_POS_Y}
+
+	// nothing happens without an action
+	actions.Update(source)
+	player.UseBlocks(actions, w, eye, target)
+	if id, _ := w.BlockAt(2, top, 2); id != 1 {
+		t.Fatalf("target changed to %d without an action", id)
+	}
+
+	click(source, actions, input.Mouse("left"))
+	player.UseBlocks(actions, w, eye, target)
+	if id, _ := w.BlockAt(2, top, 2); id != 0 {
+		t.Fatalf("broken block is %d, want air", id)
+	}
+	// held down, the button breaks once
+	source.Press(input.Mouse("left"))
+	actions.Update(source)
+	actions.Update(source)
+	player.UseBlocks(actions, w, eye, Target{Hit: true, Block: [3]int{3, top, 3}, Id: 1, Face: world.FACE_POS_Y})
+	if id, _ := w.BlockAt(3, top, 3); id != 1 {
+		t.Fatalf("held break button broke another block")
+	}
+	source.Release(input.Mouse("left"))
+
+	// placed on the face the crosshair points at
+	click(source, actions, input.Mouse("right"))
+	player.UseBlocks(actions, w, eye, Target{Hit: true, Block: [3]int{5, top, 5}, Id: 1, Face: world.FACE_POS_Y})
+	if id, _ := w.BlockAt(5, world.FLAT_HEIGHT, 5); id != 1 {
+		t.Errorf("placed block is %d, want the first block", id)
+	}
+
+	// never inside the camera, nor over another block
+	click(source, actions, input.Mouse("right"))
+	player.UseBlocks(actions, w, eye, Target{Hit: true, Block: [3]int{6, top, 6}, Id: 1, Face: world.FACE_NEG_Y})
+	if id, _ := w.BlockAt(6, top-1, 6); id != 1 {
+		t.Errorf("block under the target replaced by %d", id)
+	}
+	click(source, actions, input.Mouse("right"))
+	player.UseBlocks(actions, w, eye, Target{Hit: true, Block: [3]int{4, top, 4}, Id: 1, Face: world.FACE_POS_Y})
+	if id, _ := w.BlockAt(4, world.FLAT_HEIGHT, 4); id != 0 {
+		t.Errorf("block %d placed inside the camera", id)
+	}
+
+	// nothing to hit
+	click(source, actions, input.Mouse("left"))
+	player.UseBlocks(actions, w, eye, Target{})
+	if id, _ := w.BlockAt(0, 0, 0); id != 1 {
+		t.Errorf("block broken without a target")
+	}
+}
+
+func TestPickBlock(t *testing.T) {
+	w := newTestWorld(t)
+	source := input.NewFakeSource()
+	actions := input.NewMap(TEST_BINDINGS)
+	player := Player{}
+
+	steps := []struct {
+		scroll float64
+		want   string
+	}{
+		{-1, "stone"},
+		{-1, "glass"},
+		// wraps around both ways
+		{-1, "dirt"},
+		{1, "glass"},
+		{1, "stone"},
+		// a notch per block, however many scrolled in an update
+		{-2, "dirt"},
+		{3, "dirt"},
+	}
+	for _, step := range steps {
+		source.Scroll(step.scroll)
+		actions.Update(source)
+		player.UseBlocks(actions, w, mgl32.Vec3{}, Target{})
+		if held := player.HeldBlockDef(w.Registry); held == nil || held.Name != step.want {
+			t.Fatalf("scrolling by %g holds %v, want %s", step.scroll, held, step.want)
+		}
+	}
+
+	if held := (&Player{}).HeldBlockDef(nil); held != nil {
+		t.Errorf("held block %v without a registry", held)
+	}
+}
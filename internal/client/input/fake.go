@@ -0,0 +1,41 @@
+package input
+
+// FakeSource is a Source driven by code instead of a device, for exercising
+// gameplay code without a window.
+type FakeSource struct {
+	down map[Button]bool
+	// scrolling since the last poll, and during the current update
+	pending float64
+	scroll  float64
+}
+
+func NewFakeSource() *FakeSource {
+	return &FakeSource{down: make(map[Button]bool)}
+}
+
+// Press holds a button down until it is released.
+func (self *FakeSource) Press(button Button) {
+	self.down[button] = true
+}
+
+func (self *FakeSource) Release(button Button) {
+	delete(self.down, button)
+}
+
+// Scroll scrolls the wheel up by amount, or down if negative, for the next
+// update.
+func (self *FakeSource) Scroll(amount float64) {
+	self.pending += amount
+}
+
+func (self *FakeSource) Poll() {
+	self.scroll, self.pending = self.pending, 0
+}
+
+func (self *FakeSource) Down(button Button) bool {
+	return self.down[button]
+}
+
+func (self *FakeSource) Scrolled() float64 {
+	return self.scroll
+}
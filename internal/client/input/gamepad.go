@@ -0,0 +1,191 @@
+package input
+
+import "math"
+
+// Gamepad buttons, in the order of the GLFW gamepad API.
+const (
+	GAMEPAD_A = iota
+	GAMEPAD_B
+	GAMEPAD_X
+	GAMEPAD_Y
+	GAMEPAD_LEFT_BUMPER
+	GAMEPAD_RIGHT_BUMPER
+	GAMEPAD_BACK
+	GAMEPAD_START
+	GAMEPAD_GUIDE
+	GAMEPAD_LEFT_THUMB
+	GAMEPAD_RIGHT_THUMB
+	GAMEPAD_DPAD_UP
+	GAMEPAD_DPAD_RIGHT
+	GAMEPAD_DPAD_DOWN
+	GAMEPAD_DPAD_LEFT
+
+	GAMEPAD_BUTTON_COUNT
+)
+
+// Names of the gamepad buttons, as bound to actions.
+var gamepadButtonNames = [GAMEPAD_BUTTON_COUNT]string{
+	"a", "b", "x", "y", "lb", "rb", "back", "start", "guide", "ls", "rs",
+	"dpad_up", "dpad_right", "dpad_down", "dpad_left",
+}
+
+// Gamepad axes, in the order of the GLFW gamepad API.
+const (
+	AXIS_LEFT_X = iota
+	AXIS_LEFT_Y
+	AXIS_RIGHT_X
+	AXIS_RIGHT_Y
+	AXIS_LEFT_TRIGGER
+	AXIS_RIGHT_TRIGGER
+
+	AXIS_COUNT
+)
+
+// Names of the triggers, bound to actions like buttons.
+const (
+	GAMEPAD_LEFT_TRIGGER  = "lt"
+	GAMEPAD_RIGHT_TRIGGER = "rt"
+)
+
+func Gamepad(name string) Button {
+	return Button{DEVICE_GAMEPAD, name}
+}
+
+// GamepadButtonName returns the name of a gamepad button, or "" for an
+// invalid one.
+func GamepadButtonName(button int) string {
+	if button < 0 || button >= GAMEPAD_BUTTON_COUNT {
+		return ""
+	}
+	return gamepadButtonNames[button]
+}
+
+// IsGamepadButton reports whether a gamepad button or trigger has the name.
+func IsGamepadButton(name string) bool {
+	return gamepadButtonIndex(name) >= 0 || name == GAMEPAD_LEFT_TRIGGER || name == GAMEPAD_RIGHT_TRIGGER
+}
+
+func gamepadButtonIndex(name string) int {
+	for i, buttonName := range gamepadButtonNames {
+		if buttonName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// GamepadState is the state of a gamepad at one point. Sticks go from -1 to
+// 1, down and right being positive; triggers from 0 at rest to 1.
+type GamepadState struct {
+	Buttons [GAMEPAD_BUTTON_COUNT]bool
+	Axes    [AXIS_COUNT]float32
+}
+
+// StickResponse shapes the raw position of a stick.
+type StickResponse struct {
+	// fraction of the range around the centre ignored, for sticks that don't
+	// rest exactly at the centre
+	Deadzone float32
+	// exponent applied to the distance from the deadzone, above 1 for finer
+	// control near the centre
+	Curve float32
+}
+
+// Apply returns the position of a stick after the radial deadzone and the
+// curve, with its distance from the centre rescaled from the edge of the
+// deadzone to 1.
+func (self StickResponse) Apply(x, y float32) (float32, float32) {
+	length := float32(math.Hypot(float64(x), float64(y)))
+	if length <= self.Deadzone || length == 0 {
+		return 0, 0
+	}
+
+	scaled := (minf(length, 1) - self.Deadzone) / (1 - self.Deadzone)
+	if self.Curve > 0 {
+		scaled = float32(math.Pow(float64(scaled), float64(self.Curve)))
+	}
+	return x / length * scaled, y / length * scaled
+}
+
+func minf(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// GamepadSource is the Source of the buttons and triggers of a gamepad, read
+// through Read on every poll. Its sticks are read separately, with Stick.
+type GamepadSource struct {
+	// returns the state of the gamepad, and false if none is connected
+	Read func() (GamepadState, bool)
+	// how far a trigger must be pulled to count as down
+	TriggerThreshold float32
+	// shapes both sticks
+	Response StickResponse
+
+	state     GamepadState
+	previous  GamepadState
+	connected bool
+}
+
+func (self *GamepadSource) Poll() {
+	self.previous = self.state
+	self.state, self.connected = GamepadState{}, false
+	if self.Read != nil {
+		self.state, self.connected = self.Read()
+	}
+}
+
+// Scrolled is always 0: gamepads have no wheel.
+func (self *GamepadSource) Scrolled() float64 {
+	return 0
+}
+
+func (self *GamepadSource) Connected() bool {
+	return self.connected
+}
+
+func (self *GamepadSource) Down(button Button) bool {
+	if button.Device != DEVICE_GAMEPAD {
+		return false
+	}
+	switch button.Name {
+	case GAMEPAD_LEFT_TRIGGER:
+		return self.state.Axes[AXIS_LEFT_TRIGGER] >= self.TriggerThreshold
+	case GAMEPAD_RIGHT_TRIGGER:
+		return self.state.Axes[AXIS_RIGHT_TRIGGER] >= self.TriggerThreshold
+	}
+	index := gamepadButtonIndex(button.Name)
+	return index >= 0 && self.state.Buttons[index]
+}
+
+// Pressed reports whether a gamepad button went down on the last poll.
+func (self *GamepadSource) Pressed(button int) bool {
+	return self.state.Buttons[button] && !self.previous.Buttons[button]
+}
+
+// Stick returns the shaped position of the left or right stick.
+func (self *GamepadSource) Stick(right bool) (float32, float32) {
+	if right {
+		return self.Response.Apply(self.state.Axes[AXIS_RIGHT_X], self.state.Axes[AXIS_RIGHT_Y])
+	}
+	return self.Response.Apply(self.state.Axes[AXIS_LEFT_X], self.state.Axes[AXIS_LEFT_Y])
+}
+
+// Used reports whether the gamepad was used on the last poll: a button went
+// down, or a stick or trigger is off its rest position.
+func (self *GamepadSource) Used() bool {
+	for i := range self.state.Buttons {
+		if self.Pressed(i) {
+			return true
+		}
+	}
+	for _, right := range []bool{false, true} {
+		if x, y := self.Stick(right); x != 0 || y != 0 {
+			return true
+		}
+	}
+	return self.state.Axes[AXIS_LEFT_TRIGGER] >= self.TriggerThreshold ||
+		self.state.Axes[AXIS_RIGHT_TRIGGER] >= self.TriggerThreshold
+}
@@ -0,0 +1,136 @@
+// Package input maps the keys, mouse buttons and scroll wheel of an input
+// source to named actions, so gameplay code asks whether "jump" was pressed
+// rather than which key is down. It does not depend on GLFW: the game reads
+// a window, and a FakeSource stands in for one elsewhere.
+package input
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Action is the name of something the player can do, like "move_forward".
+type Action string
+
+type Device int
+
+const (
+	DEVICE_KEY Device = iota
+	DEVICE_MOUSE
+	// the scroll wheel, as the buttons "up" and "down", down for the update
+	// they were scrolled in
+	DEVICE_SCROLL
+	// the buttons and triggers of a gamepad, see GamepadSource
+	DEVICE_GAMEPAD
+)
+
+var deviceNames = []string{"key", "mouse", "scroll", "gamepad"}
+
+func (self Device) String() string {
+	if self < 0 || int(self) >= len(deviceNames) {
+		return "unknown"
+	}
+	return deviceNames[self]
+}
+
+// Button is a key, mouse button or scroll direction, named by the source.
+type Button struct {
+	Device Device
+	Name   string
+}
+
+func Key(name string) Button {
+	return Button{DEVICE_KEY, name}
+}
+
+func Mouse(name string) Button {
+	return Button{DEVICE_MOUSE, name}
+}
+
+func Scroll(name string) Button {
+	return Button{DEVICE_SCROLL, name}
+}
+
+// Names of the scroll buttons.
+const (
+	SCROLL_UP   = "up"
+	SCROLL_DOWN = "down"
+)
+
+// ScrollNotches returns how many times a scroll button is pressed by
+// scrolling by amount, positive upwards, during an update: once per notch,
+// and at least once for any scrolling its way.
+func ScrollNotches(button Button, amount float64) int {
+	switch button.Name {
+	case SCROLL_UP:
+	case SCROLL_DOWN:
+		amount = -amount
+	default:
+		return 0
+	}
+	if amount <= 0 {
+		return 0
+	}
+	return int(math.Max(1, math.Round(amount)))
+}
+
+// String formats the button as "<device>:<name>", the form ParseButton reads.
+func (self Button) String() string {
+	return self.Device.String() + ":" + self.Name
+}
+
+// ParseButton reads a button formatted as "<device>:<name>", like "key:W",
+// "mouse:right", "scroll:up" or "gamepad:a".
+func ParseButton(text string) (Button, error) {
+	device, name, ok := strings.Cut(text, ":")
+	if !ok || name == "" {
+		return Button{}, fmt.Errorf("invalid button `%s`, expected <device>:<name>", text)
+	}
+	for i, deviceName := range deviceNames {
+		if device == deviceName {
+			return Button{Device(i), name}, nil
+		}
+	}
+	return Button{}, fmt.Errorf("unknown input device `%s`", device)
+}
+
+// Source reports the state of the buttons of some input device.
+type Source interface {
+	// Poll is called once per update, before Down, to take in the input
+	// received since the last one, like scrolling.
+	Poll()
+	// Down reports whether a button is held. Scroll buttons never are, see
+	// Scrolled.
+	Down(button Button) bool
+	// Scrolled returns how far the wheel scrolled since the last poll,
+	// positive upwards.
+	Scrolled() float64
+}
+
+// Sources combines several sources: a button is down if it is down in any
+// of them.
+type Sources []Source
+
+func (self Sources) Poll() {
+	for _, source := range self {
+		source.Poll()
+	}
+}
+
+func (self Sources) Down(button Button) bool {
+	for _, source := range self {
+		if source.Down(button) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self Sources) Scrolled() float64 {
+	amount := 0.0
+	for _, source := range self {
+		amount += source.Scrolled()
+	}
+	return amount
+}
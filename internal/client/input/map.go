@@ -0,0 +1,119 @@
+package input
+
+import "sort"
+
+// Map binds actions to buttons and tracks the state of every action across
+// updates: held while one of its buttons is down, pressed and released on
+// the updates the first went down and the last went up. The scroll wheel is
+// never held: each notch presses its actions again on the update it scrolled
+// in.
+type Map struct {
+	bindings map[Action][]Button
+	down     map[Action]bool
+	wasDown  map[Action]bool
+	// times each action was pressed on the last update, if any
+	presses map[Action]int
+}
+
+func NewMap(bindings map[Action][]Button) *Map {
+	self := &Map{
+		bindings: make(map[Action][]Button),
+		down:     make(map[Action]bool),
+		wasDown:  make(map[Action]bool),
+		presses:  make(map[Action]int),
+	}
+	for action, buttons := range bindings {
+		self.Bind(action, buttons...)
+	}
+	return self
+}
+
+// Bind sets the buttons of an action, replacing the previous ones.
+func (self *Map) Bind(action Action, buttons ...Button) {
+	self.bindings[action] = append([]Button(nil), buttons...)
+}
+
+// Bindings returns the buttons of an action.
+func (self *Map) Bindings(action Action) []Button {
+	return self.bindings[action]
+}
+
+// Actions returns the bound actions, sorted by name.
+func (self *Map) Actions() []Action {
+	actions := make([]Action, 0, len(self.bindings))
+	for action := range self.bindings {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i] < actions[j]
+	})
+	return actions
+}
+
+// Update polls the source and moves every action to its new state.
+func (self *Map) Update(source Source) {
+	source.Poll()
+	self.down, self.wasDown = self.wasDown, self.down
+	for action := range self.down {
+		delete(self.down, action)
+	}
+	for action := range self.presses {
+		delete(self.presses, action)
+	}
+
+	scroll := source.Scrolled()
+	for action, buttons := range self.bindings {
+		presses := 0
+		for _, button := range buttons {
+			if button.Device == DEVICE_SCROLL {
+				presses += ScrollNotches(button, scroll)
+			} else if !self.down[action] && source.Down(button) {
+				self.down[action] = true
+			}
+		}
+		if self.down[action] && !self.wasDown[action] {
+			presses++
+		}
+		if presses > 0 {
+			self.presses[action] = presses
+		}
+	}
+}
+
+// Latch makes the actions held now count as held since the previous update,
+// so they are not pressed. Used when the input of an update went elsewhere,
+// like to a key binding being changed.
+func (self *Map) Latch() {
+	for action := range self.wasDown {
+		delete(self.wasDown, action)
+	}
+	for action := range self.down {
+		self.wasDown[action] = true
+	}
+	for action := range self.presses {
+		delete(self.presses, action)
+	}
+}
+
+// Held reports whether a button of the action is down.
+func (self *Map) Held(action Action) bool {
+	return self.down[action]
+}
+
+// Pressed reports whether the action went from no button down to some, or
+// was scrolled, on the last update.
+func (self *Map) Pressed(action Action) bool {
+	return self.presses[action] > 0
+}
+
+// Presses returns how many times the action was pressed on the last update:
+// more than once only when the wheel scrolled several notches.
+func (self *Map) Presses(action Action) int {
+	return self.presses[action]
+}
+
+// Released reports whether the last button of the action went up on the
+// last update.
+func (self *Map) Released(action Action) bool {
+	return !self.down[action] && self.wasDown[action]
+}
@@ -0,0 +1,138 @@
+package input
+
+import "testing"
+
+const JUMP Action = "jump"
+
+// Checks the state of the jump action after an update.
+func expectJump(t *testing.T, actions *Map, step string, held, pressed, released bool) {
+	t.Helper()
+	if actions.Held(JUMP) != held || actions.Pressed(JUMP) != pressed || actions.Released(JUMP) != released {
+		t.Errorf("%s: held %t, pressed %t, released %t, want %t, %t, %t", step,
+			actions.Held(JUMP), actions.Pressed(JUMP), actions.Released(JUMP), held, pressed, released)
+	}
+}
+
+func TestMapPressHoldRelease(t *testing.T) {
+	source := NewFakeSource()
+	actions := NewMap(map[Action][]Button{JUMP: {Key("Space"), Mouse("left")}})
+
+	actions.Update(source)
+	expectJump(t, actions, "idle", false, false, false)
+
+	source.Press(Key("Space"))
+	actions.Update(source)
+	expectJump(t, actions, "space down", true, true, false)
+	actions.Update(source)
+	expectJump(t, actions, "space held", true, false, false)
+
+	// the action is held as long as any of its buttons is
+	source.Press(Mouse("left"))
+	actions.Update(source)
+	expectJump(t, actions, "second button down", true, false, false)
+	source.Release(Key("Space"))
+	actions.Update(source)
+	expectJump(t, actions, "first button up", true, false, false)
+
+	source.Release(Mouse("left"))
+	actions.Update(source)
+	expectJump(t, actions, "last button up", false, false, true)
+	actions.Update(source)
+	expectJump(t, actions, "idle again", false, false, false)
+
+	// unbound buttons do nothing
+	source.Press(Key("W"))
+	actions.Update(source)
+	expectJump(t, actions, "other key", false, false, false)
+}
+
+func TestMapScroll(t *testing.T) {
+	source := NewFakeSource()
+	actions := NewMap(map[Action][]Button{JUMP: {Scroll(SCROLL_DOWN)}})
+
+	source.Scroll(1)
+	actions.Update(source)
+	expectJump(t, actions, "scroll up", false, false, false)
+
+	// the wheel is never held, so every update it scrolls in presses again
+	for i := 0; i < 3; i++ {
+		source.Scroll(-1)
+		actions.Update(source)
+		expectJump(t, actions, "scroll down", false, true, false)
+		if presses := actions.Presses(JUMP); presses != 1 {
+			t.Errorf("a notch pressed %d times, want 1", presses)
+		}
+	}
+	actions.Update(source)
+	expectJump(t, actions, "after scrolling", false, false, false)
+
+	cases := []struct {
+		scroll float64
+		want   int
+	}{
+		{-3, 3},
+		// smooth scrolling presses at least once
+		{-0.2, 1},
+		{-1.6, 2},
+		{0.5, 0},
+	}
+	for _, c := range cases {
+		source.Scroll(c.scroll)
+		actions.Update(source)
+		if presses := actions.Presses(JUMP); presses != c.want {
+			t.Errorf("scrolling by %g pressed %d times, want %d", c.scroll, presses, c.want)
+		}
+	}
+
+	// scrolling while the bound key is held still presses
+	actions.Bind(JUMP, Key("Space"), Scroll(SCROLL_DOWN))
+	source.Press(Key("Space"))
+	actions.Update(source)
+	source.Scroll(-1)
+	actions.Update(source)
+	expectJump(t, actions, "scroll while held", true, true, false)
+}
+
+func TestMapLatch(t *testing.T) {
+	source := NewFakeSource()
+	actions := NewMap(map[Action][]Button{JUMP: {Key("Space")}})
+
+	source.Press(Key("Space"))
+	actions.Update(source)
+	actions.Latch()
+	expectJump(t, actions, "latched", true, false, false)
+
+	// scrolling that went elsewhere doesn't press either
+	scrolled := NewMap(map[Action][]Button{JUMP: {Scroll(SCROLL_UP)}})
+	source.Scroll(2)
+	scrolled.Update(source)
+	scrolled.Latch()
+	if scrolled.Pressed(JUMP) {
+		t.Error("latched scrolling pressed")
+	}
+	actions.Update(source)
+	expectJump(t, actions, "held after latching", true, false, false)
+
+	source.Release(Key("Space"))
+	actions.Update(source)
+	expectJump(t, actions, "released after latching", false, false, true)
+
+	// a later press counts again
+	source.Press(Key("Space"))
+	actions.Update(source)
+	expectJump(t, actions, "pressed again", true, true, false)
+}
+
+func TestMapBind(t *testing.T) {
+	source := NewFakeSource()
+	actions := NewMap(map[Action][]Button{JUMP: {Key("Space")}})
+	actions.Bind(JUMP, Key("J"))
+
+	source.Press(Key("Space"))
+	actions.Update(source)
+	expectJump(t, actions, "old button", false, false, false)
+
+	source.Press(Key("J"))
+	actions.Update(source)
+	expectJump(t, actions, "new button", true, true, false)
+}
@@ -0,0 +1,92 @@
+package render
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// BlockTextures keeps every block face texture in its own layer of a
+// GL_TEXTURE_2D_ARRAY, so mipmapping never mixes neighbouring textures the
+// way it does with an atlas.
+type BlockTextures struct {
+	// layers and images, kept for drawing without GL
+	scene.BlockImages
+	Texture uint32
+}
+
+// NewBlockTextures loads every texture named by the block definitions
+// through loadImage and uploads them as layers of a texture array. All images
+// must have the same size.
+func NewBlockTextures(registry *world.BlockRegistry, loadImage func(name string) (*image.RGBA, error)) (BlockTextures, error) {
+	images, err := scene.LoadBlockImages(registry, loadImage)
+	if err != nil {
+		return BlockTextures{}, err
+	}
+
+	texture, err := NewTextureArray(images.Images)
+	if err != nil {
+		return BlockTextures{}, err
+	}
+
+	return BlockTextures{BlockImages: images, Texture: texture}, nil
+}
+
+// NewTextureArray uploads equally sized images as the layers of a mipmapped
+// GL_TEXTURE_2D_ARRAY. The images are sRGB encoded, so sampling returns
+// linear colours for lighting and post-processing.
+func NewTextureArray(images []*image.RGBA) (uint32, error) {
+	if len(images) == 0 {
+		return 0, fmt.Errorf("cannot create a texture array without images")
+	}
+
+	size := images[0].Rect.Size()
+	for _, img := range images {
+		if img.Rect.Size() != size {
+			return 0, fmt.Errorf("texture array layers must have the same size")
+		}
+		if img.Stride != size.X*4 {
+			return 0, fmt.Errorf("Unsupported image stride")
+		}
+	}
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, texture)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.NEAREST_MIPMAP_LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.TexImage3D(
+		gl.TEXTURE_2D_ARRAY,
+		0,
+		gl.SRGB8_ALPHA8,
+		int32(size.X),
+		int32(size.Y),
+		int32(len(images)),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		nil,
+	)
+
+	for layer, img := range images {
+		gl.TexSubImage3D(
+			gl.TEXTURE_2D_ARRAY,
+			0,
+			0, 0, int32(layer),
+			int32(size.X), int32(size.Y), 1,
+			gl.RGBA,
+			gl.UNSIGNED_BYTE,
+			gl.Ptr(img.Pix),
+		)
+	}
+
+	gl.GenerateMipmap(gl.TEXTURE_2D_ARRAY)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	return texture, nil
+}
@@ -4,10 +4,9 @@ import (
 	"math"
 
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
 )
 
-var WorldUp = mgl32.Vec3{0, 1, 0}
-
 type Camera struct {
 	Position mgl32.Vec3
 	Pitch float64
@@ -53,7 +52,7 @@ func (self Camera) RotateY(value float64) {
 
 func (self Camera) ViewMatrix() mgl32.Mat4 {
 	z := self.Direction()
-	x := z.Cross(WorldUp).Normalize()
+	x := z.Cross(scene.WorldUp).Normalize()
 	y := x.Cross(z)
 	z = mgl32.Vec3{}.Sub(z)
 	return mgl32.Mat4FromRows(
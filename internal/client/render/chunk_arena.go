@@ -0,0 +1,221 @@
+package render
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+)
+
+type chunkAllocation struct {
+	vertices ArenaRange
+	indices  ArenaRange
+}
+
+// ChunkArena stores the geometry of every chunk in one shared vertex buffer
+// and one shared index buffer, so that all of them can be drawn with a single
+// glMultiDrawElementsBaseVertex call. Indices are chunk-local: each draw
+// passes its vertex offset as the base vertex.
+type ChunkArena struct {
+	Vbo uint32
+	Ebo uint32
+	Vao uint32
+
+	vertices    FreeList // in vertices
+	indices     FreeList // in indices
+	allocations map[[3]int]chunkAllocation
+
+	// scratch buffers reused between draws
+	counts       []int32
+	offsets      []unsafe.Pointer
+	baseVertices []int32
+}
+
+func NewChunkArena(vertexCapacity, indexCapacity int) *ChunkArena {
+	arena := ChunkArena{
+		vertices:    NewFreeList(vertexCapacity),
+		indices:     NewFreeList(indexCapacity),
+		allocations: make(map[[3]int]chunkAllocation),
+	}
+
+	gl.GenBuffers(1, &arena.Vbo)
+	gl.GenBuffers(1, &arena.Ebo)
+	gl.GenVertexArrays(1, &arena.Vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, arena.Vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, vertexCapacity*CHUNK_VERTEX_SIZE, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, arena.Ebo)
+	gl.BufferData(gl.ARRAY_BUFFER, indexCapacity*4, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	arena.bindVertexArray()
+
+	return &arena
+}
+
+func (self *ChunkArena) bindVertexArray() {
+	gl.BindVertexArray(self.Vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.Vbo)
+	setupChunkVertexLayout()
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, self.Ebo)
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// Copies the first oldSize bytes of buffer into a new buffer of newSize bytes
+// and returns the new buffer, deleting the old one.
+func growBuffer(buffer uint32, oldSize, newSize int) uint32 {
+	var grown uint32
+	gl.GenBuffers(1, &grown)
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, grown)
+	gl.BufferData(gl.COPY_WRITE_BUFFER, newSize, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.COPY_READ_BUFFER, buffer)
+	gl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, oldSize)
+	gl.BindBuffer(gl.COPY_READ_BUFFER, 0)
+	gl.BindBuffer(gl.COPY_WRITE_BUFFER, 0)
+	gl.DeleteBuffers(1, &buffer)
+	return grown
+}
+
+func grownSize(list *FreeList, needed int) int {
+	size := list.Size() * 2
+	if size < list.Size()+needed {
+		size = list.Size() + needed
+	}
+	return size
+}
+
+func (self *ChunkArena) alloc(vertexCount, indexCount int) (chunkAllocation, error) {
+	grown := false
+
+	vertexOffset, ok := self.vertices.Alloc(vertexCount)
+	if !ok {
+		oldSize := self.vertices.Size()
+		newSize := grownSize(&self.vertices, vertexCount)
+		self.Vbo = growBuffer(self.Vbo, oldSize*CHUNK_VERTEX_SIZE, newSize*CHUNK_VERTEX_SIZE)
+		self.vertices.Grow(newSize)
+		grown = true
+
+		vertexOffset, ok = self.vertices.Alloc(vertexCount)
+		if !ok {
+			return chunkAllocation{}, fmt.Errorf("chunk arena: cannot allocate %d vertices", vertexCount)
+		}
+	}
+
+	indexOffset, ok := self.indices.Alloc(indexCount)
+	if !ok {
+		oldSize := self.indices.Size()
+		newSize := grownSize(&self.indices, indexCount)
+		self.Ebo = growBuffer(self.Ebo, oldSize*4, newSize*4)
+		self.indices.Grow(newSize)
+		grown = true
+
+		indexOffset, ok = self.indices.Alloc(indexCount)
+		if !ok {
+			self.vertices.Free(vertexOffset, vertexCount)
+			return chunkAllocation{}, fmt.Errorf("chunk arena: cannot allocate %d indices", indexCount)
+		}
+	}
+
+	if grown {
+		self.bindVertexArray()
+	}
+
+	return chunkAllocation{
+		ArenaRange{vertexOffset, vertexCount},
+		ArenaRange{indexOffset, indexCount},
+	}, nil
+}
+
+// Upload replaces the geometry stored for the chunk at key. Indices must be
+// relative to the first of the given vertices.
+func (self *ChunkArena) Upload(key [3]int, vertices []float32, indices []uint32) error {
+	self.Remove(key)
+
+	if len(indices) == 0 {
+		return nil
+	}
+
+	vertexCount := len(vertices) / scene.CHUNK_VERTEX_COMPONENTS
+	allocation, err := self.alloc(vertexCount, len(indices))
+	if err != nil {
+		return err
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.Vbo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, allocation.vertices.Offset*CHUNK_VERTEX_SIZE, len(vertices)*4, gl.Ptr(vertices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.Ebo)
+	gl.BufferSubData(gl.ARRAY_BUFFER, allocation.indices.Offset*4, len(indices)*4, gl.Ptr(indices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	self.allocations[key] = allocation
+
+	return nil
+}
+
+// Remove releases the geometry stored for the chunk at key, if any.
+func (self *ChunkArena) Remove(key [3]int) {
+	allocation, ok := self.allocations[key]
+	if !ok {
+		return
+	}
+
+	self.vertices.Free(allocation.vertices.Offset, allocation.vertices.Size)
+	self.indices.Free(allocation.indices.Offset, allocation.indices.Size)
+	delete(self.allocations, key)
+}
+
+func (self *ChunkArena) Contains(key [3]int) bool {
+	_, ok := self.allocations[key]
+	return ok
+}
+
+// Number of chunks with geometry in the arena.
+func (self *ChunkArena) Len() int {
+	return len(self.allocations)
+}
+
+// Draw renders every stored chunk for which visible returns true (or all of
+// them if visible is nil) with a single draw call, and returns how many
+// chunks were drawn.
+func (self *ChunkArena) Draw(visible func(key [3]int) bool) int {
+	self.counts = self.counts[:0]
+	self.offsets = self.offsets[:0]
+	self.baseVertices = self.baseVertices[:0]
+
+	for key, allocation := range self.allocations {
+		if visible != nil && !visible(key) {
+			continue
+		}
+
+		self.counts = append(self.counts, int32(allocation.indices.Size))
+		self.offsets = append(self.offsets, gl.PtrOffset(allocation.indices.Offset*4))
+		self.baseVertices = append(self.baseVertices, int32(allocation.vertices.Offset))
+	}
+
+	drawCount := len(self.counts)
+	if drawCount == 0 {
+		return 0
+	}
+
+	gl.BindVertexArray(self.Vao)
+	gl.MultiDrawElementsBaseVertex(
+		gl.TRIANGLES,
+		&self.counts[0],
+		gl.UNSIGNED_INT,
+		&self.offsets[0],
+		int32(drawCount),
+		&self.baseVertices[0],
+	)
+	gl.BindVertexArray(0)
+
+	return drawCount
+}
+
+func (self *ChunkArena) Delete() {
+	gl.DeleteVertexArrays(1, &self.Vao)
+	gl.DeleteBuffers(1, &self.Vbo)
+	gl.DeleteBuffers(1, &self.Ebo)
+	self.allocations = make(map[[3]int]chunkAllocation)
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// CrosshairPass draws a square in the middle of the screen.
+type CrosshairPass struct {
+	Shader ShaderProgram
+	Color  mgl32.Vec4
+	// the crosshair is hidden in menus
+	Visible bool
+	// multiplies the size, for high DPI screens
+	Scale float32
+	vbo   uint32
+	vao   uint32
+}
+
+// NewCrosshairPass creates a crosshair extending size pixels from the
+// center of the screen.
+func NewCrosshairPass(size float32) *CrosshairPass {
+	vertices := []float32{
+		-size, -size,
+		size, -size,
+		-size, size,
+		size, -size,
+		size, size,
+		-size, size,
+	}
+
+	self := &CrosshairPass{Color: mgl32.Vec4{0.4, 0.4, 0.8, 1.0}, Visible: true, Scale: 1}
+	gl.GenBuffers(1, &self.vbo)
+	gl.GenVertexArrays(1, &self.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.BindVertexArray(self.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, nil)
+
+	gl.BindVertexArray(0)
+	return self
+}
+
+func (self *CrosshairPass) Stage() RenderStage {
+	return STAGE_UI
+}
+
+func (self *CrosshairPass) Render(frame *FrameContext) {
+	if !self.Visible {
+		return
+	}
+	self.Shader.UseProgram()
+	model := mgl32.Translate3D(float32(frame.Width)/2, float32(frame.Height)/2, 0.0).
+		Mul4(mgl32.Scale3D(self.Scale, self.Scale, 1))
+	self.Shader.SetUniformMatrix4fv("uModel", model)
+	self.Shader.SetUniform4fv("uColor", self.Color)
+
+	gl.BindVertexArray(self.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+}
+
+func (self *CrosshairPass) Delete() {
+	gl.DeleteVertexArrays(1, &self.vao)
+	gl.DeleteBuffers(1, &self.vbo)
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/fs"
+	"strings"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+)
+
+// Glyph is the atlas rectangle and metrics of a character, in pixels. The
+// rectangle has its origin at the top-left corner of the atlas image.
+type Glyph struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// offset of the glyph from the pen position, which is at the top of the
+	// line
+	XOffset int `json:"xOffset"`
+	YOffset int `json:"yOffset"`
+	// horizontal distance to the next pen position
+	Advance int `json:"advance"`
+}
+
+// FontInfo is the JSON sidecar written by cmd/fontgen next to the font atlas
+// image.
+type FontInfo struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// distance between the tops of two lines
+	LineHeight int            `json:"lineHeight"`
+	Glyphs     map[rune]Glyph `json:"glyphs"`
+}
+
+// Character drawn in place of the ones missing from a font.
+const FALLBACK_GLYPH = '?'
+
+func ParseFontInfo(data []byte) (FontInfo, error) {
+	var info FontInfo
+	err := json.Unmarshal(data, &info)
+	if err != nil {
+		return FontInfo{}, fmt.Errorf("failed to parse font: %w", err)
+	}
+
+	bounds := image.Rect(0, 0, info.Width, info.Height)
+	for r, glyph := range info.Glyphs {
+		if !image.Rect(glyph.X, glyph.Y, glyph.X+glyph.W, glyph.Y+glyph.H).In(bounds) {
+			return FontInfo{}, fmt.Errorf("glyph %q is out of the font atlas bounds", r)
+		}
+	}
+	if _, ok := info.Glyphs[FALLBACK_GLYPH]; !ok {
+		return FontInfo{}, fmt.Errorf("font has no fallback glyph %q", FALLBACK_GLYPH)
+	}
+
+	return info, nil
+}
+
+// Glyph returns the glyph of a character, or the fallback glyph if the font
+// doesn't have it.
+func (self *FontInfo) Glyph(r rune) Glyph {
+	glyph, ok := self.Glyphs[r]
+	if !ok {
+		return self.Glyphs[FALLBACK_GLYPH]
+	}
+	return glyph
+}
+
+// Measure returns the size of a text in font pixels. Lines are separated by
+// '\n'.
+func (self *FontInfo) Measure(text string) (width, height int) {
+	lines := strings.Split(text, "\n")
+	for _, line := range lines {
+		lineWidth := 0
+		for _, r := range line {
+			lineWidth += self.Glyph(r).Advance
+		}
+		if lineWidth > width {
+			width = lineWidth
+		}
+	}
+	return width, len(lines) * self.LineHeight
+}
+
+// Floats per text vertex: x, y, s, t and r, g, b, a.
+const TEXT_VERTEX_COMPONENTS = 8
+
+// Layout appends two triangles per visible character of text to vertices
+// and returns the result. x, y is the top-left corner of the text in pixels,
+// with y pointing down, and each font pixel covers scale pixels.
+func (self *FontInfo) Layout(vertices []float32, text string, x, y, scale float32, color mgl32.Vec4) []float32 {
+	penX, penY := x, y
+	for _, r := range text {
+		if r == '\n' {
+			penX = x
+			penY += float32(self.LineHeight) * scale
+			continue
+		}
+
+		glyph := self.Glyph(r)
+		if glyph.W > 0 && glyph.H > 0 {
+			x0 := penX + float32(glyph.XOffset)*scale
+			y0 := penY + float32(glyph.YOffset)*scale
+			x1 := x0 + float32(glyph.W)*scale
+			y1 := y0 + float32(glyph.H)*scale
+
+			// texture rows are uploaded bottom first
+			s0 := float32(glyph.X) / float32(self.Width)
+			s1 := float32(glyph.X+glyph.W) / float32(self.Width)
+			t0 := 1 - float32(glyph.Y)/float32(self.Height)
+			t1 := 1 - float32(glyph.Y+glyph.H)/float32(self.Height)
+
+			corners := [6][4]float32{
+				{x0, y0, s0, t0}, {x0, y1, s0, t1}, {x1, y1, s1, t1},
+				{x0, y0, s0, t0}, {x1, y1, s1, t1}, {x1, y0, s1, t0},
+			}
+			for _, c := range corners {
+				vertices = append(vertices, c[0], c[1], c[2], c[3], color[0], color[1], color[2], color[3])
+			}
+		}
+		penX += float32(glyph.Advance) * scale
+	}
+	return vertices
+}
+
+// Font is a bitmap font with its atlas uploaded to a texture.
+type Font struct {
+	FontInfo
+	Texture uint32
+}
+
+// LoadFont loads fonts/<name>.json and its atlas fonts/<name>.png.
+func LoadFont(assets fs.FS, name string) (*Font, error) {
+	data, err := fs.ReadFile(assets, "fonts/"+name+".json")
+	if err != nil {
+		return nil, err
+	}
+	info, err := ParseFontInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("font `%s`: %w", name, err)
+	}
+
+	img, err := scene.LoadImage(assets, "fonts/"+name+".png")
+	if err != nil {
+		return nil, err
+	}
+	if img.Rect.Dx() != info.Width || img.Rect.Dy() != info.Height {
+		return nil, fmt.Errorf("font `%s`: atlas is %dx%d, expected %dx%d", name, img.Rect.Dx(), img.Rect.Dy(), info.Width, info.Height)
+	}
+
+	self := &Font{FontInfo: info}
+	gl.GenTextures(1, &self.Texture)
+	gl.BindTexture(gl.TEXTURE_2D, self.Texture)
+	// scaled by whole pixels, so nearest filtering keeps glyphs crisp
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA8,
+		int32(info.Width),
+		int32(info.Height),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(img.Pix),
+	)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	return self, nil
+}
+
+func (self *Font) Delete() {
+	gl.DeleteTextures(1, &self.Texture)
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+// Framebuffer is an off-screen render target with a single colour texture
+// and an optional depth renderbuffer.
+type Framebuffer struct {
+	Fbo    uint32
+	Color  uint32
+	Depth  uint32 // 0 if the framebuffer has no depth buffer
+	Width  int32
+	Height int32
+	// internal format of the colour texture
+	Format int32
+}
+
+// NewFramebuffer creates a framebuffer whose colour texture has the given
+// internal format (e.g. gl.RGBA16F for HDR) and linear filtering.
+func NewFramebuffer(width, height int32, format int32, depth bool) (*Framebuffer, error) {
+	self := &Framebuffer{Format: format}
+	gl.GenFramebuffers(1, &self.Fbo)
+	gl.GenTextures(1, &self.Color)
+	if depth {
+		gl.GenRenderbuffers(1, &self.Depth)
+	}
+
+	err := self.Resize(width, height)
+	if err != nil {
+		self.Delete()
+		return nil, err
+	}
+	return self, nil
+}
+
+// Resize reallocates the attachments, discarding their content. Does nothing
+// if the size didn't change.
+func (self *Framebuffer) Resize(width, height int32) error {
+	if width == self.Width && height == self.Height {
+		return nil
+	}
+	self.Width, self.Height = width, height
+
+	gl.BindTexture(gl.TEXTURE_2D, self.Color)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, self.Format, width, height, 0, gl.RGBA, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, self.Fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, self.Color, 0)
+
+	if self.Depth != 0 {
+		gl.BindRenderbuffer(gl.RENDERBUFFER, self.Depth)
+		gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH_COMPONENT24, width, height)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, self.Depth)
+	}
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("framebuffer incomplete: 0x%x", status)
+	}
+	return nil
+}
+
+// Bind makes the framebuffer the render target and sets the viewport to cover
+// it.
+func (self *Framebuffer) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, self.Fbo)
+	gl.Viewport(0, 0, self.Width, self.Height)
+}
+
+// ReadPixels reads the colour attachment back into an image, top row first.
+// Values are clamped to [0, 1], so this is meant for framebuffers holding
+// the final image.
+func (self *Framebuffer) ReadPixels() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(self.Width), int(self.Height)))
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, self.Fbo)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, self.Width, self.Height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+
+	// OpenGL rows start at the bottom
+	row := make([]byte, img.Stride)
+	for y := 0; y < img.Rect.Dy()/2; y++ {
+		top := img.Pix[y*img.Stride : (y+1)*img.Stride]
+		bottom := img.Pix[(img.Rect.Dy()-1-y)*img.Stride : (img.Rect.Dy()-y)*img.Stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+	return img
+}
+
+func (self *Framebuffer) Delete() {
+	gl.DeleteFramebuffers(1, &self.Fbo)
+	gl.DeleteTextures(1, &self.Color)
+	if self.Depth != 0 {
+		gl.DeleteRenderbuffers(1, &self.Depth)
+	}
+}
@@ -0,0 +1,110 @@
+package render
+
+// A contiguous range of units (vertices, indices, bytes...) inside an arena.
+type ArenaRange struct {
+	Offset int
+	Size   int
+}
+
+// FreeList manages suballocations of a linear address space of a given size.
+// It holds no GPU state, so it can be exercised without a GL context.
+type FreeList struct {
+	size int
+	free []ArenaRange // sorted by offset, adjacent ranges are always merged
+}
+
+func NewFreeList(size int) FreeList {
+	list := FreeList{size: size}
+	if size > 0 {
+		list.free = []ArenaRange{{0, size}}
+	}
+	return list
+}
+
+func (self *FreeList) Size() int {
+	return self.size
+}
+
+// Total number of unallocated units (not necessarily contiguous).
+func (self *FreeList) Available() int {
+	total := 0
+	for _, r := range self.free {
+		total += r.Size
+	}
+	return total
+}
+
+// Size of the biggest allocation that can currently succeed.
+func (self *FreeList) Largest() int {
+	largest := 0
+	for _, r := range self.free {
+		if r.Size > largest {
+			largest = r.Size
+		}
+	}
+	return largest
+}
+
+// Alloc reserves size units using a first-fit search and returns their offset.
+func (self *FreeList) Alloc(size int) (int, bool) {
+	if size <= 0 {
+		return 0, size == 0
+	}
+
+	for i, r := range self.free {
+		if r.Size < size {
+			continue
+		}
+
+		if r.Size == size {
+			self.free = append(self.free[:i], self.free[i+1:]...)
+		} else {
+			self.free[i] = ArenaRange{r.Offset + size, r.Size - size}
+		}
+		return r.Offset, true
+	}
+
+	return 0, false
+}
+
+// Free returns a range previously obtained from Alloc to the list.
+func (self *FreeList) Free(offset, size int) {
+	if size <= 0 {
+		return
+	}
+
+	// index of the first free range after the one being released
+	i := 0
+	for i < len(self.free) && self.free[i].Offset < offset {
+		i++
+	}
+
+	mergePrev := i > 0 && self.free[i-1].Offset+self.free[i-1].Size == offset
+	mergeNext := i < len(self.free) && offset+size == self.free[i].Offset
+
+	switch {
+	case mergePrev && mergeNext:
+		self.free[i-1].Size += size + self.free[i].Size
+		self.free = append(self.free[:i], self.free[i+1:]...)
+	case mergePrev:
+		self.free[i-1].Size += size
+	case mergeNext:
+		self.free[i].Offset = offset
+		self.free[i].Size += size
+	default:
+		self.free = append(self.free, ArenaRange{})
+		copy(self.free[i+1:], self.free[i:])
+		self.free[i] = ArenaRange{offset, size}
+	}
+}
+
+// Grow extends the address space to newSize, making the new tail available.
+func (self *FreeList) Grow(newSize int) {
+	if newSize <= self.size {
+		return
+	}
+
+	oldSize := self.size
+	self.size = newSize
+	self.Free(oldSize, newSize-oldSize)
+}
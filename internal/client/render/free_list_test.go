@@ -0,0 +1,90 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreeListAllocFirstFit(t *testing.T) {
+	list := NewFreeList(100)
+	a, _ := list.Alloc(10)
+	b, _ := list.Alloc(20)
+	c, _ := list.Alloc(10)
+	if a != 0 || b != 10 || c != 30 {
+		t.Fatalf("got offsets %d, %d, %d, want 0, 10, 30", a, b, c)
+	}
+
+	// frees a 20 unit hole before the 60 unit tail
+	list.Free(b, 20)
+	offset, ok := list.Alloc(15)
+	if !ok || offset != 10 {
+		t.Fatalf("Alloc(15) = %d, %t, want the first hole at 10", offset, ok)
+	}
+	offset, ok = list.Alloc(10)
+	if !ok || offset != 40 {
+		t.Fatalf("Alloc(10) = %d, %t, want the tail at 40 as the hole has 5 left", offset, ok)
+	}
+	if list.Available() != 55 {
+		t.Fatalf("Available() = %d, want 55", list.Available())
+	}
+}
+
+func TestFreeListFreeMergesNeighbours(t *testing.T) {
+	list := NewFreeList(30)
+	a, _ := list.Alloc(10)
+	b, _ := list.Alloc(10)
+	c, _ := list.Alloc(10)
+
+	list.Free(a, 10)
+	list.Free(c, 10)
+	want := []ArenaRange{{0, 10}, {20, 10}}
+	if !reflect.DeepEqual(list.free, want) {
+		t.Fatalf("free ranges %v, want %v", list.free, want)
+	}
+
+	list.Free(b, 10)
+	want = []ArenaRange{{0, 30}}
+	if !reflect.DeepEqual(list.free, want) {
+		t.Fatalf("free ranges %v, want %v after freeing the middle", list.free, want)
+	}
+	if list.Largest() != 30 {
+		t.Fatalf("Largest() = %d, want 30", list.Largest())
+	}
+}
+
+func TestFreeListAllocFails(t *testing.T) {
+	list := NewFreeList(30)
+	list.Alloc(10)
+	b, _ := list.Alloc(10)
+	list.Alloc(10)
+	list.Free(b, 10)
+
+	if _, ok := list.Alloc(11); ok {
+		t.Fatal("Alloc(11) succeeded with only 10 contiguous units free")
+	}
+	if list.Available() != 10 {
+		t.Fatalf("Available() = %d after a failed Alloc, want 10", list.Available())
+	}
+	if _, ok := list.Alloc(0); !ok {
+		t.Fatal("Alloc(0) failed")
+	}
+}
+
+func TestFreeListGrow(t *testing.T) {
+	list := NewFreeList(20)
+	list.Alloc(10)
+	list.Grow(50)
+
+	want := []ArenaRange{{10, 40}}
+	if !reflect.DeepEqual(list.free, want) {
+		t.Fatalf("free ranges %v, want the free tail merged with the new one, %v", list.free, want)
+	}
+	if list.Size() != 50 {
+		t.Fatalf("Size() = %d, want 50", list.Size())
+	}
+
+	list.Grow(40)
+	if list.Size() != 50 {
+		t.Fatalf("Size() = %d after growing to a smaller size, want 50", list.Size())
+	}
+}
@@ -0,0 +1,39 @@
+package render
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Planes (a, b, c, d) of a view frustum, with normals pointing inwards.
+type Frustum [6]mgl32.Vec4
+
+// Extracts the frustum planes from a projection * view matrix.
+func NewFrustum(m mgl32.Mat4) Frustum {
+	r0, r1, r2, r3 := m.Row(0), m.Row(1), m.Row(2), m.Row(3)
+	return Frustum{
+		r3.Add(r0), // left
+		r3.Sub(r0), // right
+		r3.Add(r1), // bottom
+		r3.Sub(r1), // top
+		r3.Add(r2), // near
+		r3.Sub(r2), // far
+	}
+}
+
+// Reports whether the axis aligned box may be visible. Boxes that are near a
+// frustum corner can produce false positives, which is fine for culling.
+func (self Frustum) IntersectsAABB(min, max mgl32.Vec3) bool {
+	for _, p := range self {
+		// the box corner furthest along the plane normal
+		v := min
+		for i := 0; i < 3; i++ {
+			if p[i] >= 0 {
+				v[i] = max[i]
+			}
+		}
+
+		if p[0]*v[0]+p[1]*v[1]+p[2]*v[2]+p[3] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,288 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// vertex shader shared by the post-processing passes
+	POST_VERTEX_SHADER = "shaders/post/post.vert"
+	// number of blur iterations applied to the bloom buffer
+	BLOOM_BLUR_PASSES = 2
+)
+
+// Fragment shaders used by the post-processing chain, loaded from
+// shaders/post/<name>.frag.
+var POST_SHADERS = []string{
+	"bloom_extract", "blur", "bloom", "underwater", "tonemap", "gamma", "fxaa",
+}
+
+func PostShaderPath(name string) string {
+	return "shaders/post/" + name + ".frag"
+}
+
+// PostPass is a fullscreen pass of the post-processing chain, reading the
+// output of the previous pass from the tInput sampler.
+type PostPass struct {
+	Name string
+	// toggled by the user
+	Enabled bool
+	// key of the pass fragment shader in PostChain.Shaders
+	Shader string
+	// whether the pass applies this frame; always if nil
+	Active func(chain *PostChain) bool
+	// sets the uniforms of the pass before it draws, and may render
+	// intermediate buffers; may be nil
+	Prepare func(chain *PostChain, shader ShaderProgram, input *Framebuffer)
+}
+
+// PostChain renders the scene into an HDR framebuffer, then runs it through
+// a list of passes, each reading the output of the previous one, the last
+// one drawing to the default framebuffer.
+type PostChain struct {
+	Passes []*PostPass
+	// post-processing programs by name, see POST_SHADERS
+	Shaders map[string]ShaderProgram
+	// the scene is drawn into this framebuffer, between Begin and Present
+	Scene *Framebuffer
+	// framebuffer the last pass draws into; the default one if nil
+	Output *Framebuffer
+
+	Exposure        float32
+	Gamma           float32
+	BloomThreshold  float32
+	BloomIntensity  float32
+	Underwater      bool
+	UnderwaterColor mgl32.Vec3
+	// seconds, for animated passes
+	Time float32
+
+	targets [2]*Framebuffer
+	bloom   [2]*Framebuffer
+	vao     uint32
+}
+
+// DefaultPostPasses returns the standard passes in order: effects working on
+// HDR colour first, then tone mapping down to LDR, gamma correction and
+// anti-aliasing.
+func DefaultPostPasses() []*PostPass {
+	return []*PostPass{
+		{Name: "bloom", Enabled: true, Shader: "bloom", Prepare: prepareBloom},
+		{
+			Name: "underwater", Enabled: true, Shader: "underwater",
+			Active: func(chain *PostChain) bool {
+				return chain.Underwater
+			},
+			Prepare: func(chain *PostChain, shader ShaderProgram, input *Framebuffer) {
+				shader.SetUniform3fv("uTint", chain.UnderwaterColor)
+				shader.SetUniform1f("uTime", chain.Time)
+			},
+		},
+		{
+			Name: "tonemap", Enabled: true, Shader: "tonemap",
+			Prepare: func(chain *PostChain, shader ShaderProgram, input *Framebuffer) {
+				shader.SetUniform1f("uExposure", chain.Exposure)
+			},
+		},
+		{
+			Name: "gamma", Enabled: true, Shader: "gamma",
+			Prepare: func(chain *PostChain, shader ShaderProgram, input *Framebuffer) {
+				shader.SetUniform1f("uGamma", chain.Gamma)
+			},
+		},
+		{Name: "fxaa", Enabled: true, Shader: "fxaa"},
+	}
+}
+
+func NewPostChain(width, height int32) (*PostChain, error) {
+	self := &PostChain{
+		Passes:          DefaultPostPasses(),
+		Shaders:         make(map[string]ShaderProgram),
+		Exposure:        1.0,
+		Gamma:           2.2,
+		BloomThreshold:  1.0,
+		BloomIntensity:  0.8,
+		UnderwaterColor: mgl32.Vec3{0.2, 0.45, 0.7},
+	}
+
+	var err error
+	self.Scene, err = NewFramebuffer(width, height, gl.RGBA16F, true)
+	if err != nil {
+		self.Delete()
+		return nil, err
+	}
+	for i := range self.targets {
+		self.targets[i], err = NewFramebuffer(width, height, gl.RGBA16F, false)
+		if err != nil {
+			self.Delete()
+			return nil, err
+		}
+	}
+	// bloom is blurred at half resolution, which also widens it for free
+	for i := range self.bloom {
+		self.bloom[i], err = NewFramebuffer(width/2, height/2, gl.RGBA16F, false)
+		if err != nil {
+			self.Delete()
+			return nil, err
+		}
+	}
+
+	gl.GenVertexArrays(1, &self.vao)
+	return self, nil
+}
+
+// Resize matches the framebuffers to the size of the window.
+func (self *PostChain) Resize(width, height int32) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+
+	err := self.Scene.Resize(width, height)
+	for _, target := range self.targets {
+		if err == nil {
+			err = target.Resize(width, height)
+		}
+	}
+	for _, target := range self.bloom {
+		if err == nil {
+			err = target.Resize(width/2, height/2)
+		}
+	}
+	return err
+}
+
+// Pass returns the pass with the given name, or nil.
+func (self *PostChain) Pass(name string) *PostPass {
+	for _, pass := range self.Passes {
+		if pass.Name == name {
+			return pass
+		}
+	}
+	return nil
+}
+
+// SetEnabled toggles a pass by name.
+func (self *PostChain) SetEnabled(name string, enabled bool) error {
+	pass := self.Pass(name)
+	if pass == nil {
+		return fmt.Errorf("no post-processing pass named `%s`", name)
+	}
+	pass.Enabled = enabled
+	return nil
+}
+
+// Begin binds the scene framebuffer, everything drawn until Present is
+// post-processed.
+func (self *PostChain) Begin() {
+	self.Scene.Bind()
+}
+
+// Present runs the enabled passes over the scene and draws the result into
+// the output framebuffer, leaving it bound with a cleared depth buffer for
+// the UI.
+func (self *PostChain) Present(width, height int32) {
+	passes := make([]*PostPass, 0, len(self.Passes))
+	for _, pass := range self.Passes {
+		if pass.Enabled && (pass.Active == nil || pass.Active(self)) {
+			passes = append(passes, pass)
+		}
+	}
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.BindVertexArray(self.vao)
+
+	input := self.Scene
+	for i, pass := range passes {
+		shader := self.Shaders[pass.Shader]
+		if pass.Prepare != nil {
+			pass.Prepare(self, shader, input)
+		}
+
+		if i == len(passes)-1 {
+			self.bindOutput(width, height)
+		} else {
+			self.targets[i%2].Bind()
+		}
+		self.draw(shader, input)
+
+		if i < len(passes)-1 {
+			input = self.targets[i%2]
+		}
+	}
+
+	gl.BindVertexArray(0)
+	gl.Enable(gl.DEPTH_TEST)
+
+	if len(passes) == 0 {
+		var output uint32
+		if self.Output != nil {
+			output = self.Output.Fbo
+		}
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, self.Scene.Fbo)
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, output)
+		gl.BlitFramebuffer(0, 0, self.Scene.Width, self.Scene.Height, 0, 0, width, height, gl.COLOR_BUFFER_BIT, gl.LINEAR)
+		self.bindOutput(width, height)
+	}
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+}
+
+func (self *PostChain) bindOutput(width, height int32) {
+	if self.Output != nil {
+		self.Output.Bind()
+		return
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, width, height)
+}
+
+// Draws a fullscreen triangle with the shader, reading input. The target
+// framebuffer and the vertex array must already be bound.
+func (self *PostChain) draw(shader ShaderProgram, input *Framebuffer) {
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, input.Color)
+	shader.SetUniformSampler("tInput", 0)
+	shader.UseProgram()
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+}
+
+// Extracts the emissive parts of the scene and blurs them into the bloom
+// buffer, which the bloom pass then adds back on top of the input.
+func prepareBloom(chain *PostChain, shader ShaderProgram, input *Framebuffer) {
+	extract := chain.Shaders["bloom_extract"]
+	blur := chain.Shaders["blur"]
+
+	extract.SetUniform1f("uThreshold", chain.BloomThreshold)
+	chain.bloom[0].Bind()
+	chain.draw(extract, input)
+
+	for i := 0; i < BLOOM_BLUR_PASSES; i++ {
+		blur.SetUniform2fv("uDirection", mgl32.Vec2{1 / float32(chain.bloom[0].Width), 0})
+		chain.bloom[1].Bind()
+		chain.draw(blur, chain.bloom[0])
+
+		blur.SetUniform2fv("uDirection", mgl32.Vec2{0, 1 / float32(chain.bloom[1].Height)})
+		chain.bloom[0].Bind()
+		chain.draw(blur, chain.bloom[1])
+	}
+
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, chain.bloom[0].Color)
+	gl.ActiveTexture(gl.TEXTURE0)
+	shader.SetUniformSampler("tBloom", 1)
+	shader.SetUniform1f("uIntensity", chain.BloomIntensity)
+}
+
+func (self *PostChain) Delete() {
+	targets := []*Framebuffer{self.Scene, self.targets[0], self.targets[1], self.bloom[0], self.bloom[1]}
+	for _, target := range targets {
+		if target != nil {
+			target.Delete()
+		}
+	}
+	if self.vao != 0 {
+		gl.DeleteVertexArrays(1, &self.vao)
+	}
+}
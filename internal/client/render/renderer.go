@@ -0,0 +1,147 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+)
+
+// RenderStage orders the passes of a frame. Stages run in order, and passes
+// within a stage in the order they were added.
+type RenderStage int
+
+const (
+	// opaque world geometry, drawn with depth testing and writing
+	STAGE_WORLD_OPAQUE RenderStage = iota
+	// blended world geometry, drawn after everything opaque
+	STAGE_WORLD_TRANSLUCENT
+	// highlights drawn on top of the world, like the block selection
+	STAGE_OVERLAY
+	// interface drawn in pixel space after post-processing
+	STAGE_UI
+)
+
+// FrameContext is the state of the frame being rendered, shared by every
+// pass.
+type FrameContext struct {
+	Projection mgl32.Mat4
+	View       mgl32.Mat4
+	// camera position in world space
+	CameraPosition mgl32.Vec3
+	// vertical field of view in radians and aspect ratio of the projection
+	Fovy   float32
+	Aspect float32
+	// size of the framebuffer, in pixels
+	Width  int32
+	Height int32
+	// seconds since the start of the game
+	Time float64
+	Sky  scene.SkyState
+	// the camera is inside a liquid
+	Underwater bool
+}
+
+// Ortho is the pixel space projection used by the UI, with the origin in
+// the bottom left corner.
+func (self *FrameContext) Ortho() mgl32.Mat4 {
+	return mgl32.Ortho2D(0, float32(self.Width), 0, float32(self.Height))
+}
+
+// RenderPass draws one part of the frame. Each pass owns its shader and GL
+// state, and restores whatever state it changes from the defaults (depth
+// testing and face culling enabled, no blending).
+type RenderPass interface {
+	Stage() RenderStage
+	Render(frame *FrameContext)
+}
+
+// PreparePass is implemented by passes that need to draw off-screen before
+// the frame starts, like shadow maps.
+type PreparePass interface {
+	Prepare(frame *FrameContext)
+}
+
+// Renderer draws a frame by running its passes in stage order. The world
+// stages and overlays are drawn into the post-processing chain, if any, and
+// the UI on top of its output.
+type Renderer struct {
+	Passes []RenderPass
+	// nil draws the scene straight to the output
+	Post *PostChain
+	// framebuffer the frame ends up in; the default one if nil
+	Output *Framebuffer
+	camera UniformBuffer
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{camera: NewCameraBuffer()}
+}
+
+// Add inserts a pass after the other passes of its stage.
+func (self *Renderer) Add(pass RenderPass) {
+	i := len(self.Passes)
+	for i > 0 && self.Passes[i-1].Stage() > pass.Stage() {
+		i--
+	}
+
+	self.Passes = append(self.Passes, nil)
+	copy(self.Passes[i+1:], self.Passes[i:])
+	self.Passes[i] = pass
+}
+
+// Render draws a frame into the output framebuffer.
+func (self *Renderer) Render(frame *FrameContext) error {
+	self.camera.UpdateCamera(&CameraUniforms{
+		Projection: frame.Projection,
+		View:       frame.View,
+		Ortho:      frame.Ortho(),
+	})
+
+	for _, pass := range self.Passes {
+		if prepare, ok := pass.(PreparePass); ok {
+			prepare.Prepare(frame)
+		}
+	}
+
+	if self.Post != nil {
+		err := self.Post.Resize(frame.Width, frame.Height)
+		if err != nil {
+			return err
+		}
+		self.Post.Time = float32(frame.Time)
+		self.Post.Underwater = frame.Underwater
+		self.Post.Output = self.Output
+		self.Post.Begin()
+	} else if self.Output != nil {
+		self.Output.Bind()
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.Viewport(0, 0, frame.Width, frame.Height)
+	}
+
+	horizon := frame.Sky.Horizon
+	gl.ClearColor(horizon[0], horizon[1], horizon[2], 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	i := 0
+	for ; i < len(self.Passes) && self.Passes[i].Stage() < STAGE_UI; i++ {
+		self.Passes[i].Render(frame)
+	}
+
+	if self.Post != nil {
+		self.Post.Present(frame.Width, frame.Height)
+	} else {
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+	}
+
+	for ; i < len(self.Passes); i++ {
+		self.Passes[i].Render(frame)
+	}
+	gl.BindVertexArray(0)
+
+	return nil
+}
+
+func (self *Renderer) Delete() {
+	self.camera.Delete()
+}
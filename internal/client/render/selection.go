@@ -1,10 +1,135 @@
 package render
 
-import _ "embed"
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
 
-//go:embed "selection.vert"
-var SelectionVsSource string
+// Unit cube of the selection box, each vertex being a corner position and
+// the index of its face, in the order of the world.FACE_* constants.
+var selectionBoxVertices = []uint32{
+	// +X
+	1, 0, 1, 0,
+	1, 0, 0, 0,
+	1, 1, 1, 0,
+	1, 1, 0, 0,
 
-//go:embed "selection.frag"
-var SelectionFsSource string
+	// -X
+	0, 0, 0, 1,
+	0, 0, 1, 1,
+	0, 1, 0, 1,
+	0, 1, 1, 1,
 
+	// +Y
+	0, 1, 1, 2,
+	1, 1, 1, 2,
+	0, 1, 0, 2,
+	1, 1, 0, 2,
+
+	// -Y
+	0, 0, 0, 3,
+	1, 0, 0, 3,
+	0, 0, 1, 3,
+	1, 0, 1, 3,
+
+	// +Z
+	0, 0, 1, 4,
+	1, 0, 1, 4,
+	0, 1, 1, 4,
+	1, 1, 1, 4,
+
+	// -Z
+	1, 0, 0, 5,
+	0, 0, 0, 5,
+	1, 1, 0, 5,
+	0, 1, 0, 5,
+}
+
+var selectionBoxIndices = []uint32{
+	// +X
+	0, 1, 2,
+	1, 3, 2,
+
+	// -X
+	4, 5, 6,
+	5, 7, 6,
+
+	// +Y
+	8, 9, 10,
+	9, 11, 10,
+
+	// -Y
+	12, 13, 14,
+	13, 15, 14,
+
+	// +Z
+	16, 17, 18,
+	17, 19, 18,
+
+	// -Z
+	20, 21, 22,
+	21, 23, 22,
+}
+
+// SelectionPass outlines the block the player is looking at.
+type SelectionPass struct {
+	Shader ShaderProgram
+	// position of the outlined block, if Visible
+	Block   [3]int
+	Visible bool
+	vbo     uint32
+	ebo     uint32
+	vao     uint32
+}
+
+func NewSelectionPass() *SelectionPass {
+	self := &SelectionPass{}
+	gl.GenBuffers(1, &self.vbo)
+	gl.GenBuffers(1, &self.ebo)
+	gl.GenVertexArrays(1, &self.vao)
+
+	gl.BindVertexArray(self.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(selectionBoxVertices)*4, gl.Ptr(selectionBoxVertices), gl.STATIC_DRAW)
+
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribIPointerWithOffset(0, 3, gl.UNSIGNED_INT, 4*4, 0)
+
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribIPointerWithOffset(1, 1, gl.UNSIGNED_INT, 4*4, 3*4)
+
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, self.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(selectionBoxIndices)*4, gl.Ptr(selectionBoxIndices), gl.STATIC_DRAW)
+
+	gl.BindVertexArray(0)
+	return self
+}
+
+func (self *SelectionPass) Stage() RenderStage {
+	return STAGE_OVERLAY
+}
+
+func (self *SelectionPass) Render(frame *FrameContext) {
+	if !self.Visible {
+		return
+	}
+
+	model := mgl32.Translate3D(float32(self.Block[0]), float32(self.Block[1]), float32(self.Block[2]))
+	self.Shader.UseProgram()
+	self.Shader.SetUniformMatrix4fv("uModel", model)
+
+	// pulled towards the camera so the outline wins over the block faces
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(-1.0, -1.0)
+	gl.BindVertexArray(self.vao)
+	gl.DrawElements(gl.TRIANGLES, int32(len(selectionBoxIndices)), gl.UNSIGNED_INT, nil)
+	gl.BindVertexArray(0)
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+}
+
+func (self *SelectionPass) Delete() {
+	gl.DeleteVertexArrays(1, &self.vao)
+	gl.DeleteBuffers(1, &self.vbo)
+	gl.DeleteBuffers(1, &self.ebo)
+}
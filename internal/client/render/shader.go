@@ -2,6 +2,7 @@ package render
 
 import (
 	"fmt"
+	"io/fs"
 	"strings"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
@@ -35,6 +36,22 @@ func NewShader(source string, shaderType Shader) (Shader, error) {
 
 type ShaderProgram uint32
 
+type UniformInfo struct {
+	Location int32
+	Type     uint32 // gl.FLOAT_VEC3, gl.SAMPLER_2D_ARRAY...
+	Size     int32  // number of elements for arrays, 1 otherwise
+}
+
+// Active uniforms of every linked program, looked up once after linking so
+// that setting a uniform doesn't need a glGetUniformLocation call.
+var uniformTables = make(map[ShaderProgram]map[string]UniformInfo)
+
+// Binding points assigned to uniform blocks, by block name. Every program
+// that declares one of these blocks gets it bound when linked.
+var uniformBlockBindings = map[string]uint32{
+	CAMERA_BLOCK: CAMERA_BINDING,
+}
+
 func NewShaderProgram(vs, fs Shader) (ShaderProgram, error) {
 	program := gl.CreateProgram()
 	gl.AttachShader(program, vs)
@@ -50,37 +67,328 @@ func NewShaderProgram(vs, fs Shader) (ShaderProgram, error) {
 		log := strings.Repeat("\x00", int(logLength+1))
 		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
 
+		gl.DeleteProgram(program)
 		return ShaderProgram(0), fmt.Errorf("failed to link program: %v", log)
 	}
 
-	return ShaderProgram(program), nil
+	self := ShaderProgram(program)
+	self.introspect()
+
+	return self, nil
+}
+
+// Fills the uniform table of the program and binds its uniform blocks.
+func (self ShaderProgram) introspect() {
+	var count, maxLength int32
+	gl.GetProgramiv(uint32(self), gl.ACTIVE_UNIFORMS, &count)
+	gl.GetProgramiv(uint32(self), gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+
+	table := make(map[string]UniformInfo, count)
+	buf := make([]uint8, maxLength+1)
+	for i := int32(0); i < count; i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(uint32(self), uint32(i), int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+
+		location := gl.GetUniformLocation(uint32(self), gl.Str(name+"\x00"))
+		if location == -1 {
+			continue // member of a uniform block
+		}
+
+		// arrays are reported as "name[0]", allow setting them by plain name
+		name = strings.TrimSuffix(name, "[0]")
+		table[name] = UniformInfo{location, xtype, size}
+	}
+	uniformTables[self] = table
+
+	for block, binding := range uniformBlockBindings {
+		index := gl.GetUniformBlockIndex(uint32(self), gl.Str(block+"\x00"))
+		if index != gl.INVALID_INDEX {
+			gl.UniformBlockBinding(uint32(self), index, binding)
+		}
+	}
+}
+
+// LoadShaderProgram reads, compiles and links a vertex and a fragment shader
+// from the given file system.
+func LoadShaderProgram(assets fs.FS, vsName, fsName string) (ShaderProgram, error) {
+	vsSource, err := fs.ReadFile(assets, vsName)
+	if err != nil {
+		return 0, err
+	}
+
+	fsSource, err := fs.ReadFile(assets, fsName)
+	if err != nil {
+		return 0, err
+	}
+
+	vertShader, err := NewShader(string(vsSource), gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", vsName, err)
+	}
+	defer gl.DeleteShader(vertShader)
+
+	fragShader, err := NewShader(string(fsSource), gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fsName, err)
+	}
+	defer gl.DeleteShader(fragShader)
+
+	return NewShaderProgram(vertShader, fragShader)
+}
+
+func (self ShaderProgram) Delete() {
+	if self != 0 {
+		gl.DeleteProgram(uint32(self))
+		delete(uniformTables, self)
+	}
 }
 
 func (self ShaderProgram) UseProgram() {
 	gl.UseProgram(uint32(self))
 }
 
-func (self ShaderProgram) SetUniformMatrix4fv(name string, value mgl32.Mat4) error {
-	location := gl.GetUniformLocation(uint32(self), gl.Str(name + "\x00"))
-	if location == -1 {
-		return fmt.Errorf("glGetUniformLocation returned -1 for name `%s`. This could mean there is no uniform with this name.", name)
+// Uniform returns the cached information about an active uniform.
+func (self ShaderProgram) Uniform(name string) (UniformInfo, bool) {
+	info, ok := uniformTables[self][name]
+	return info, ok
+}
+
+var uniformTypeNames = map[uint32]string{
+	gl.FLOAT:                   "float",
+	gl.FLOAT_VEC2:              "vec2",
+	gl.FLOAT_VEC3:              "vec3",
+	gl.FLOAT_VEC4:              "vec4",
+	gl.INT:                     "int",
+	gl.INT_VEC2:                "ivec2",
+	gl.INT_VEC3:                "ivec3",
+	gl.INT_VEC4:                "ivec4",
+	gl.UNSIGNED_INT:            "uint",
+	gl.BOOL:                    "bool",
+	gl.FLOAT_MAT3:              "mat3",
+	gl.FLOAT_MAT4:              "mat4",
+	gl.SAMPLER_2D:              "sampler2D",
+	gl.SAMPLER_2D_ARRAY:        "sampler2DArray",
+	gl.SAMPLER_2D_SHADOW:       "sampler2DShadow",
+	gl.SAMPLER_2D_ARRAY_SHADOW: "sampler2DArrayShadow",
+	gl.SAMPLER_3D:              "sampler3D",
+	gl.SAMPLER_CUBE:            "samplerCube",
+}
+
+func uniformTypeName(xtype uint32) string {
+	name, ok := uniformTypeNames[xtype]
+	if !ok {
+		return fmt.Sprintf("type 0x%x", xtype)
+	}
+	return name
+}
+
+func isSampler(xtype uint32) bool {
+	switch xtype {
+	case gl.SAMPLER_1D, gl.SAMPLER_2D, gl.SAMPLER_3D, gl.SAMPLER_CUBE,
+		gl.SAMPLER_1D_SHADOW, gl.SAMPLER_2D_SHADOW, gl.SAMPLER_1D_ARRAY,
+		gl.SAMPLER_2D_ARRAY, gl.SAMPLER_1D_ARRAY_SHADOW, gl.SAMPLER_2D_ARRAY_SHADOW,
+		gl.SAMPLER_2D_MULTISAMPLE, gl.SAMPLER_CUBE_SHADOW, gl.SAMPLER_BUFFER,
+		gl.SAMPLER_2D_RECT, gl.INT_SAMPLER_2D, gl.INT_SAMPLER_2D_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_2D, gl.UNSIGNED_INT_SAMPLER_2D_ARRAY:
+		return true
+	}
+	return false
+}
+
+// Looks up a uniform and checks that it has one of the given types and room
+// for count elements.
+func (self ShaderProgram) location(name string, count int, types ...uint32) (int32, error) {
+	info, ok := uniformTables[self][name]
+	if !ok {
+		return -1, fmt.Errorf("no active uniform named `%s`. It may not exist or have been optimized out.", name)
+	}
+
+	match := false
+	for _, xtype := range types {
+		if info.Type == xtype {
+			match = true
+		}
+	}
+	if !match && !(types[0] == gl.INT && isSampler(info.Type)) {
+		return -1, fmt.Errorf("uniform `%s` is a %s, cannot set it as a %s", name, uniformTypeName(info.Type), uniformTypeName(types[0]))
+	}
+
+	if int32(count) > info.Size {
+		return -1, fmt.Errorf("uniform `%s` has %d elements, cannot set %d", name, info.Size, count)
+	}
+
+	return info.Location, nil
+}
+
+func (self ShaderProgram) SetUniform1i(name string, value int32) error {
+	location, err := self.location(name, 1, gl.INT, gl.BOOL)
+	if err != nil {
+		return err
 	}
 
-	self.UseProgram()
-	gl.UniformMatrix4fv(location, 1, false, &value[0])
+	gl.ProgramUniform1i(uint32(self), location, value)
+	return nil
+}
+
+// SetUniformSampler sets the texture unit a sampler uniform reads from.
+func (self ShaderProgram) SetUniformSampler(name string, unit int32) error {
+	return self.SetUniform1i(name, unit)
+}
+
+func (self ShaderProgram) SetUniform1ui(name string, value uint32) error {
+	location, err := self.location(name, 1, gl.UNSIGNED_INT)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform1ui(uint32(self), location, value)
+	return nil
+}
+
+func (self ShaderProgram) SetUniform1f(name string, value float32) error {
+	location, err := self.location(name, 1, gl.FLOAT)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform1f(uint32(self), location, value)
+	return nil
+}
+
+func (self ShaderProgram) SetUniform2fv(name string, value mgl32.Vec2) error {
+	location, err := self.location(name, 1, gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
 
+	gl.ProgramUniform2fv(uint32(self), location, 1, &value[0])
 	return nil
 }
 
 func (self ShaderProgram) SetUniform3fv(name string, value mgl32.Vec3) error {
-	location := gl.GetUniformLocation(uint32(self), gl.Str(name + "\x00"))
-	if location == -1 {
-		return fmt.Errorf("glGetUniformLocation returned -1 for name `%s`. This could mean there is no uniform with this name.", name)
+	location, err := self.location(name, 1, gl.FLOAT_VEC3)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform3fv(uint32(self), location, 1, &value[0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniform4fv(name string, value mgl32.Vec4) error {
+	location, err := self.location(name, 1, gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform4fv(uint32(self), location, 1, &value[0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniformMatrix3fv(name string, value mgl32.Mat3) error {
+	location, err := self.location(name, 1, gl.FLOAT_MAT3)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniformMatrix3fv(uint32(self), location, 1, false, &value[0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniformMatrix4fv(name string, value mgl32.Mat4) error {
+	location, err := self.location(name, 1, gl.FLOAT_MAT4)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniformMatrix4fv(uint32(self), location, 1, false, &value[0])
+	return nil
+}
+
+// Array setters, writing len(values) elements starting at the first one.
+
+func (self ShaderProgram) SetUniform1iArray(name string, values []int32) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	location, err := self.location(name, len(values), gl.INT, gl.BOOL)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform1iv(uint32(self), location, int32(len(values)), &values[0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniform1fArray(name string, values []float32) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	location, err := self.location(name, len(values), gl.FLOAT)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform1fv(uint32(self), location, int32(len(values)), &values[0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniform2fvArray(name string, values []mgl32.Vec2) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	location, err := self.location(name, len(values), gl.FLOAT_VEC2)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform2fv(uint32(self), location, int32(len(values)), &values[0][0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniform3fvArray(name string, values []mgl32.Vec3) error {
+	if len(values) == 0 {
+		return nil
 	}
 
-	self.UseProgram()
-	gl.Uniform3fv(location, 1, &value[0])
+	location, err := self.location(name, len(values), gl.FLOAT_VEC3)
+	if err != nil {
+		return err
+	}
 
+	gl.ProgramUniform3fv(uint32(self), location, int32(len(values)), &values[0][0])
 	return nil
 }
 
+func (self ShaderProgram) SetUniform4fvArray(name string, values []mgl32.Vec4) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	location, err := self.location(name, len(values), gl.FLOAT_VEC4)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniform4fv(uint32(self), location, int32(len(values)), &values[0][0])
+	return nil
+}
+
+func (self ShaderProgram) SetUniformMatrix4fvArray(name string, values []mgl32.Mat4) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	location, err := self.location(name, len(values), gl.FLOAT_MAT4)
+	if err != nil {
+		return err
+	}
+
+	gl.ProgramUniformMatrix4fv(uint32(self), location, int32(len(values)), false, &values[0][0])
+	return nil
+}
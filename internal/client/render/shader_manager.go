@@ -0,0 +1,121 @@
+package render
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// A shader program built by a ShaderManager. Program always holds the last
+// version that compiled and linked successfully.
+type ManagedProgram struct {
+	Program ShaderProgram
+	VsName  string
+	FsName  string
+	// called after the program has been replaced by a reload, so that
+	// uniforms can be set again on the new program
+	OnReload func(program ShaderProgram)
+
+	modTimes [2]time.Time
+}
+
+// ShaderManager builds shader programs from source files in an asset file
+// system and, when watching, rebuilds them whenever their files change.
+type ShaderManager struct {
+	assets   fs.FS
+	programs []*ManagedProgram
+	// whether Poll checks the source files for changes
+	Watch bool
+	// minimum time between two checks
+	PollInterval time.Duration
+
+	lastPoll time.Time
+}
+
+func NewShaderManager(assets fs.FS, watch bool) *ShaderManager {
+	return &ShaderManager{
+		assets:       assets,
+		Watch:        watch,
+		PollInterval: 500 * time.Millisecond,
+	}
+}
+
+func (self *ShaderManager) modTimes(vsName, fsName string) [2]time.Time {
+	var times [2]time.Time
+	for i, name := range [2]string{vsName, fsName} {
+		info, err := fs.Stat(self.assets, name)
+		if err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// Load builds a program from a vertex and a fragment shader file and starts
+// tracking it.
+func (self *ShaderManager) Load(vsName, fsName string) (*ManagedProgram, error) {
+	modTimes := self.modTimes(vsName, fsName)
+	program, err := LoadShaderProgram(self.assets, vsName, fsName)
+	if err != nil {
+		return nil, err
+	}
+
+	managed := ManagedProgram{
+		Program:  program,
+		VsName:   vsName,
+		FsName:   fsName,
+		modTimes: modTimes,
+	}
+	self.programs = append(self.programs, &managed)
+
+	return &managed, nil
+}
+
+// Reload rebuilds a program from its current sources. On failure, the
+// previous program is kept.
+func (self *ShaderManager) Reload(managed *ManagedProgram) error {
+	managed.modTimes = self.modTimes(managed.VsName, managed.FsName)
+	program, err := LoadShaderProgram(self.assets, managed.VsName, managed.FsName)
+	if err != nil {
+		return err
+	}
+
+	managed.Program.Delete()
+	managed.Program = program
+	if managed.OnReload != nil {
+		managed.OnReload(program)
+	}
+
+	return nil
+}
+
+// Poll reloads the programs whose source files changed since they were last
+// built. Failures are logged and leave the last good program in place. Does
+// nothing unless Watch is set.
+func (self *ShaderManager) Poll() {
+	if !self.Watch || time.Since(self.lastPoll) < self.PollInterval {
+		return
+	}
+	self.lastPoll = time.Now()
+
+	for _, managed := range self.programs {
+		if self.modTimes(managed.VsName, managed.FsName) == managed.modTimes {
+			continue
+		}
+
+		err := self.Reload(managed)
+		if err != nil {
+			fmt.Printf("failed to reload %s + %s: %v\n", managed.VsName, managed.FsName, err)
+		} else {
+			fmt.Printf("reloaded %s + %s\n", managed.VsName, managed.FsName)
+		}
+	}
+}
+
+// Delete frees every program built by the manager.
+func (self *ShaderManager) Delete() {
+	for _, managed := range self.programs {
+		managed.Program.Delete()
+	}
+	self.programs = nil
+}
@@ -0,0 +1,198 @@
+package render
+
+import (
+	"math"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// number of shadow cascades, must match chunk.frag
+	SHADOW_CASCADES = 3
+	// resolution of each cascade of the shadow map
+	SHADOW_MAP_SIZE = 2048
+	// blend between logarithmic (1) and uniform (0) cascade splits
+	SHADOW_SPLIT_LAMBDA = 0.75
+	// closest distance covered by the shadows
+	SHADOW_NEAR = 0.1
+	// how far towards the light blocks outside the view still cast shadows
+	SHADOW_CASTER_DISTANCE = 64
+	// texture unit the shadow map is bound to while drawing the world
+	SHADOW_TEXTURE_UNIT = 1
+)
+
+// ShadowMap renders the depth of the chunks as seen from a directional light
+// into a depth texture array, one layer per cascade. Each cascade covers a
+// slice of the camera frustum, the first ones being smaller to keep shadows
+// close to the camera sharp.
+type ShadowMap struct {
+	Shader  ShaderProgram
+	Texture uint32
+	Fbo     uint32
+	Size    int32
+	// far distance of each cascade from the camera
+	Splits [SHADOW_CASCADES]float32
+	// world to light clip space transform of each cascade
+	Matrices [SHADOW_CASCADES]mgl32.Mat4
+	// size of a shadow map texel of each cascade, in world units
+	TexelSizes [SHADOW_CASCADES]float32
+}
+
+func NewShadowMap(size int32) *ShadowMap {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT24, size, size, SHADOW_CASCADES, 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	// linear filtering with comparison gives 2x2 PCF for free
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	border := [4]float32{1, 1, 1, 1}
+	gl.TexParameterfv(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_BORDER_COLOR, &border[0])
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+
+	var fbo uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, texture, 0, 0)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+
+	return &ShadowMap{Texture: texture, Fbo: fbo, Size: size}
+}
+
+// CascadeSplits divides [near, far] into SHADOW_CASCADES slices, blending a
+// logarithmic and a uniform split by lambda.
+func CascadeSplits(near, far, lambda float32) [SHADOW_CASCADES]float32 {
+	var splits [SHADOW_CASCADES]float32
+	for i := range splits {
+		p := float64(i+1) / SHADOW_CASCADES
+		log := float64(near) * math.Pow(float64(far/near), p)
+		uniform := float64(near) + float64(far-near)*p
+		splits[i] = float32(float64(lambda)*log + float64(1-lambda)*uniform)
+	}
+	return splits
+}
+
+// Corners of the slice of a perspective camera frustum between the near and
+// far distances, in world space.
+func frustumSliceCorners(inverseView mgl32.Mat4, fovy, aspect, near, far float32) [8]mgl32.Vec3 {
+	var corners [8]mgl32.Vec3
+	tan := float32(math.Tan(float64(fovy) / 2))
+	for i, distance := range [2]float32{near, far} {
+		h := distance * tan
+		w := h * aspect
+		for j, xy := range [4][2]float32{{-w, -h}, {w, -h}, {-w, h}, {w, h}} {
+			corner := inverseView.Mul4x1(mgl32.Vec4{xy[0], xy[1], -distance, 1})
+			corners[i*4+j] = corner.Vec3()
+		}
+	}
+	return corners
+}
+
+// Light space transform of a cascade covering the given frustum slice. The
+// slice is wrapped in a sphere, so the size of the cascade doesn't change as
+// the camera turns, and the sphere center is snapped to whole texels so
+// shadow edges don't shimmer as the camera moves.
+func cascadeMatrix(corners [8]mgl32.Vec3, lightDirection mgl32.Vec3, size int32) (mgl32.Mat4, float32) {
+	var center mgl32.Vec3
+	for _, corner := range corners {
+		center = center.Add(corner)
+	}
+	center = center.Mul(1.0 / float32(len(corners)))
+
+	var radius float32
+	for _, corner := range corners {
+		radius = float32(math.Max(float64(radius), float64(corner.Sub(center).Len())))
+	}
+	radius = float32(math.Ceil(float64(radius)*16) / 16)
+
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(lightDirection[1])) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1}
+	}
+	lightView := mgl32.LookAtV(mgl32.Vec3{}, lightDirection.Mul(-1), up)
+
+	texel := 2 * radius / float32(size)
+	c := lightView.Mul4x1(center.Vec4(1))
+	c[0] = float32(math.Floor(float64(c[0]/texel))) * texel
+	c[1] = float32(math.Floor(float64(c[1]/texel))) * texel
+
+	// the light looks down -Z, towards the light is +Z
+	projection := mgl32.Ortho(
+		c[0]-radius, c[0]+radius,
+		c[1]-radius, c[1]+radius,
+		-c[2]-radius-SHADOW_CASTER_DISTANCE, -c[2]+radius,
+	)
+	return projection.Mul4(lightView), texel
+}
+
+// Update fits the cascades to the camera frustum up to distance far and
+// to the direction towards the light.
+func (self *ShadowMap) Update(view mgl32.Mat4, fovy, aspect, far float32, lightDirection mgl32.Vec3) {
+	self.Splits = CascadeSplits(SHADOW_NEAR, far, SHADOW_SPLIT_LAMBDA)
+
+	inverseView := view.Inv()
+	near := float32(SHADOW_NEAR)
+	for i, split := range self.Splits {
+		corners := frustumSliceCorners(inverseView, fovy, aspect, near, split)
+		self.Matrices[i], self.TexelSizes[i] = cascadeMatrix(corners, lightDirection.Normalize(), self.Size)
+		near = split
+	}
+}
+
+// Render draws the depth of the chunks in the arena into every cascade. The
+// viewport and framebuffer are restored to the default ones afterwards.
+func (self *ShadowMap) Render(arena *ChunkArena) {
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, self.Fbo)
+	gl.Viewport(0, 0, self.Size, self.Size)
+	// every face casts shadows, and the offset keeps lit faces from
+	// shadowing themselves
+	gl.Disable(gl.CULL_FACE)
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(2.0, 4.0)
+
+	self.Shader.UseProgram()
+	self.Shader.SetUniformMatrix4fv("uModel", mgl32.Ident4())
+	for i, matrix := range self.Matrices {
+		gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, self.Texture, 0, int32(i))
+		gl.Clear(gl.DEPTH_BUFFER_BIT)
+		self.Shader.SetUniformMatrix4fv("uLightMatrix", matrix)
+
+		frustum := NewFrustum(matrix)
+		arena.Draw(func(key [3]int) bool {
+			return frustum.IntersectsAABB(chunkBounds(key))
+		})
+	}
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.Enable(gl.CULL_FACE)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+}
+
+// Bind binds the shadow map and sets the uniforms chunk.frag samples it with.
+func (self *ShadowMap) Bind(program ShaderProgram, strength float32) {
+	gl.ActiveTexture(gl.TEXTURE0 + SHADOW_TEXTURE_UNIT)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, self.Texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	program.SetUniformSampler("tShadowMap", SHADOW_TEXTURE_UNIT)
+	program.SetUniformMatrix4fvArray("uShadowMatrices", self.Matrices[:])
+	program.SetUniform1fArray("uCascadeSplits", self.Splits[:])
+	program.SetUniform1fArray("uShadowTexelSizes", self.TexelSizes[:])
+	program.SetUniform1f("uShadowStrength", strength)
+}
+
+func (self *ShadowMap) Delete() {
+	gl.DeleteFramebuffers(1, &self.Fbo)
+	gl.DeleteTextures(1, &self.Texture)
+}
@@ -0,0 +1,58 @@
+package render
+
+import "github.com/go-gl/gl/v4.1-core/gl"
+
+// Angular radius of the sun and moon discs, in radians.
+const (
+	SUN_SIZE  = 0.045
+	MOON_SIZE = 0.035
+)
+
+// SkyRenderer draws the sky dome behind everything else: the gradient, the
+// sun and moon and the stars, all computed per pixel from the view direction
+// of a fullscreen triangle generated in the vertex shader.
+type SkyRenderer struct {
+	Shader ShaderProgram
+	// draw stars at night
+	Stars bool
+	vao   uint32
+}
+
+func NewSkyRenderer() SkyRenderer {
+	var vao uint32
+	gl.GenVertexArrays(1, &vao)
+	return SkyRenderer{Stars: true, vao: vao}
+}
+
+func (self *SkyRenderer) Stage() RenderStage {
+	return STAGE_WORLD_OPAQUE
+}
+
+// Render draws the sky of the frame. It must be the first pass of the frame,
+// as it neither tests nor writes depth.
+func (self *SkyRenderer) Render(frame *FrameContext) {
+	sky := frame.Sky
+	self.Shader.SetUniform3fv("uZenith", sky.Zenith)
+	self.Shader.SetUniform3fv("uHorizon", sky.Horizon)
+	self.Shader.SetUniform3fv("uSunDirection", sky.SunDirection)
+	self.Shader.SetUniform3fv("uMoonDirection", sky.MoonDirection)
+	self.Shader.SetUniform1f("uSunSize", SUN_SIZE)
+	self.Shader.SetUniform1f("uMoonSize", MOON_SIZE)
+	self.Shader.SetUniformMatrix3fv("uStarRotation", sky.StarRotation)
+	if self.Stars {
+		self.Shader.SetUniform1f("uStars", sky.Stars)
+	} else {
+		self.Shader.SetUniform1f("uStars", 0)
+	}
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.DepthMask(false)
+
+	self.Shader.UseProgram()
+	gl.BindVertexArray(self.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	gl.BindVertexArray(0)
+
+	gl.DepthMask(true)
+	gl.Enable(gl.DEPTH_TEST)
+}
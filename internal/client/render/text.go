@@ -0,0 +1,127 @@
+package render
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TextPass draws the text queued during a frame with a bitmap font, in a
+// single batch on top of everything else. Positions are in pixels from the
+// top-left corner of the framebuffer.
+type TextPass struct {
+	Shader ShaderProgram
+	// nil until the resources are loaded; text is dropped meanwhile
+	Font *Font
+	// pixels per font pixel
+	Scale float32
+	// colour of the shadow drawn one font pixel below and right of the text,
+	// none if transparent
+	Shadow mgl32.Vec4
+
+	vertices []float32
+	// size of the vertex buffer, in floats
+	capacity int
+	vbo      uint32
+	vao      uint32
+}
+
+func NewTextPass() *TextPass {
+	self := &TextPass{
+		Scale:  2,
+		Shadow: mgl32.Vec4{0, 0, 0, 0.6},
+	}
+	gl.GenBuffers(1, &self.vbo)
+	gl.GenVertexArrays(1, &self.vao)
+
+	gl.BindVertexArray(self.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.vbo)
+	stride := int32(TEXT_VERTEX_COMPONENTS * 4)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, stride, nil)
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, stride, gl.PtrOffset(2*4))
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(2, 4, gl.FLOAT, false, stride, gl.PtrOffset(4*4))
+
+	gl.BindVertexArray(0)
+	return self
+}
+
+// Draw queues text to be drawn this frame, with its top-left corner at x, y.
+func (self *TextPass) Draw(text string, x, y float32, color mgl32.Vec4) {
+	self.DrawScaled(text, x, y, self.Scale, color)
+}
+
+// DrawScaled is Draw with scale pixels per font pixel instead of Scale.
+func (self *TextPass) DrawScaled(text string, x, y, scale float32, color mgl32.Vec4) {
+	if self.Font == nil {
+		return
+	}
+	if self.Shadow[3] > 0 {
+		self.vertices = self.Font.Layout(self.vertices, text, x+scale, y+scale, scale, self.Shadow)
+	}
+	self.vertices = self.Font.Layout(self.vertices, text, x, y, scale, color)
+}
+
+// Measure returns the size in pixels text would take if drawn.
+func (self *TextPass) Measure(text string) (width, height float32) {
+	if self.Font == nil {
+		return 0, 0
+	}
+	w, h := self.Font.Measure(text)
+	return float32(w) * self.Scale, float32(h) * self.Scale
+}
+
+func (self *TextPass) Stage() RenderStage {
+	return STAGE_UI
+}
+
+func (self *TextPass) Render(frame *FrameContext) {
+	self.Flush(frame)
+}
+
+// Flush draws and clears the text queued so far.
+func (self *TextPass) Flush(frame *FrameContext) {
+	if len(self.vertices) == 0 || self.Font == nil {
+		self.vertices = self.vertices[:0]
+		return
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.vbo)
+	if len(self.vertices) > self.capacity {
+		self.capacity = cap(self.vertices)
+		gl.BufferData(gl.ARRAY_BUFFER, self.capacity*4, nil, gl.STREAM_DRAW)
+	}
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(self.vertices)*4, gl.Ptr(self.vertices))
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	// flips the ortho projection so y points down from the top
+	model := mgl32.Translate3D(0, float32(frame.Height), 0).Mul4(mgl32.Scale3D(1, -1, 1))
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, self.Font.Texture)
+	self.Shader.SetUniformSampler("tFont", 0)
+	self.Shader.SetUniformMatrix4fv("uModel", model)
+	self.Shader.UseProgram()
+
+	// the flip also turns the quads clockwise
+	gl.Disable(gl.CULL_FACE)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+
+	gl.BindVertexArray(self.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(self.vertices)/TEXT_VERTEX_COMPONENTS))
+	gl.BindVertexArray(0)
+
+	gl.Disable(gl.BLEND)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.CULL_FACE)
+
+	self.vertices = self.vertices[:0]
+}
+
+func (self *TextPass) Delete() {
+	gl.DeleteVertexArrays(1, &self.vao)
+	gl.DeleteBuffers(1, &self.vbo)
+}
@@ -1,10 +1,136 @@
 package render
 
-import _ "embed"
+import (
+	"math"
 
-//go:embed "ui.vert"
-var UiVsSource string
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/client/ui"
+)
 
-//go:embed "ui.frag"
-var UiFsSource string
+// UiPass lays out and draws the widgets of a ui.Root over the frame. It is
+// the ui.Painter of the widgets: rectangles are drawn right away with the UI
+// shader, and text is batched by its own TextPass, flushed whenever the clip
+// rectangle changes so text is clipped like the rest of its widget.
+type UiPass struct {
+	Shader ShaderProgram
+	Text   *TextPass
+	// widgets drawn; nothing if nil
+	Root *ui.Root
 
+	// frame being drawn, during Render
+	frame *FrameContext
+	vbo   uint32
+	vao   uint32
+}
+
+func NewUiPass() *UiPass {
+	// unit square, scaled to each rectangle
+	vertices := []float32{
+		0, 0,
+		0, 1,
+		1, 1,
+		0, 0,
+		1, 1,
+		1, 0,
+	}
+
+	self := &UiPass{Text: NewTextPass()}
+	gl.GenBuffers(1, &self.vbo)
+	gl.GenVertexArrays(1, &self.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, self.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.BindVertexArray(self.vao)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, nil)
+
+	gl.BindVertexArray(0)
+	return self
+}
+
+func (self *UiPass) Stage() RenderStage {
+	return STAGE_UI
+}
+
+// Measure implements ui.TextMeasurer, in font pixels.
+func (self *UiPass) Measure(text string) (width, height float32) {
+	if self.Text.Font == nil {
+		return 0, 0
+	}
+	w, h := self.Text.Font.Measure(text)
+	return float32(w), float32(h)
+}
+
+// Blending on, and no depth testing or face culling, since the rectangles
+// are flipped along with the y axis.
+func (self *UiPass) setState() {
+	gl.Disable(gl.CULL_FACE)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+}
+
+func (self *UiPass) Render(frame *FrameContext) {
+	if self.Root == nil {
+		return
+	}
+
+	self.frame = frame
+	self.Root.Layout(float32(frame.Width), float32(frame.Height))
+	self.setState()
+	self.Root.Paint(self)
+	self.Text.Flush(frame)
+	self.frame = nil
+
+	gl.Disable(gl.SCISSOR_TEST)
+	gl.Disable(gl.BLEND)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.CULL_FACE)
+}
+
+// FillRect implements ui.Painter.
+func (self *UiPass) FillRect(rect ui.Rect, color mgl32.Vec4) {
+	// flips the ortho projection so y points down from the top
+	model := mgl32.Translate3D(rect.X, float32(self.frame.Height)-rect.Y, 0).
+		Mul4(mgl32.Scale3D(rect.W, -rect.H, 1))
+
+	self.Shader.SetUniformMatrix4fv("uModel", model)
+	self.Shader.SetUniform4fv("uColor", color)
+	self.Shader.UseProgram()
+
+	gl.BindVertexArray(self.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, 6)
+	gl.BindVertexArray(0)
+}
+
+// DrawText implements ui.Painter.
+func (self *UiPass) DrawText(text string, x, y, scale float32, color mgl32.Vec4) {
+	self.Text.DrawScaled(text, x, y, scale, color)
+}
+
+// SetClip implements ui.Painter with the scissor test.
+func (self *UiPass) SetClip(clip *ui.Rect) {
+	// text queued so far belongs to the previous clip
+	self.Text.Flush(self.frame)
+	self.setState()
+
+	if clip == nil {
+		gl.Disable(gl.SCISSOR_TEST)
+		return
+	}
+	x0 := int32(math.Floor(float64(clip.X)))
+	y0 := int32(math.Floor(float64(clip.Y)))
+	x1 := int32(math.Ceil(float64(clip.X + clip.W)))
+	y1 := int32(math.Ceil(float64(clip.Y + clip.H)))
+	// scissor boxes start at the bottom left
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(x0, self.frame.Height-y1, x1-x0, y1-y0)
+}
+
+func (self *UiPass) Delete() {
+	self.Text.Delete()
+	gl.DeleteVertexArrays(1, &self.vao)
+	gl.DeleteBuffers(1, &self.vbo)
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// uniform block holding the per-frame camera data, see CameraUniforms
+	CAMERA_BLOCK   = "Camera"
+	CAMERA_BINDING = 0
+)
+
+// UniformBuffer is a uniform buffer object attached to a binding point, from
+// which every program declaring a block bound to that point reads.
+type UniformBuffer struct {
+	Ubo     uint32
+	Binding uint32
+	Size    int
+}
+
+func NewUniformBuffer(binding uint32, size int) UniformBuffer {
+	var ubo uint32
+	gl.GenBuffers(1, &ubo)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, ubo)
+	gl.BufferData(gl.UNIFORM_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, ubo)
+
+	return UniformBuffer{ubo, binding, size}
+}
+
+// Update replaces the first size bytes of the buffer with the data at ptr.
+func (self UniformBuffer) Update(ptr unsafe.Pointer, size int) {
+	gl.BindBuffer(gl.UNIFORM_BUFFER, self.Ubo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, size, ptr)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+}
+
+func (self *UniformBuffer) Delete() {
+	gl.DeleteBuffers(1, &self.Ubo)
+}
+
+// CameraUniforms mirrors the std140 layout of the Camera uniform block:
+//
+//	layout (std140) uniform Camera {
+//		mat4 uProjection;
+//		mat4 uView;
+//		mat4 uOrtho;
+//	};
+type CameraUniforms struct {
+	Projection mgl32.Mat4
+	View       mgl32.Mat4
+	// pixel space projection used by the UI
+	Ortho mgl32.Mat4
+}
+
+func NewCameraBuffer() UniformBuffer {
+	return NewUniformBuffer(CAMERA_BINDING, int(unsafe.Sizeof(CameraUniforms{})))
+}
+
+func (self UniformBuffer) UpdateCamera(camera *CameraUniforms) {
+	self.Update(unsafe.Pointer(camera), int(unsafe.Sizeof(*camera)))
+}
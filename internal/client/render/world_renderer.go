@@ -1,223 +1,192 @@
 package render
 
 import (
-	_ "embed"
 	"fmt"
-	"image"
+	"io/fs"
+	"math"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
 	"github.com/hexagon-0/voxel-game/internal/common/world"
 )
 
-//go:embed "chunk.vert"
-var ChunkVsSource string
+const (
+	// bytes of each chunk vertex, laid out as described by scene
+	CHUNK_VERTEX_SIZE = scene.CHUNK_VERTEX_COMPONENTS * 4
 
-//go:embed "chunk.frag"
-var ChunkFsSource string
-
-type BlockRepo map[world.BlockId]image.Rectangle
-
-type ChunkMesh struct {
-	ModelMatrix  mgl32.Mat4
-	VboSize      int
-	EboSize      int
-	Vbo          uint32
-	Ebo          uint32
-	Vao          uint32
-	ElementCount int32
-}
-
-// Calculate number of voxels in the worst case scenario
-func checkerboard(w, h, d uint) uint {
-	loW, loH, loD := (w >> 1), (h >> 1), (d >> 1)
-	hiW, hiH, hiD := loW+(w&1), loH+(h&1), loD+(d&1)
-
-	return hiH*(hiW*hiD+loW*loD) + loH*(loW*hiD+hiW*loD)
-}
-
-func NewChunkMesh(width, height, depth uint) ChunkMesh {
-	// number of cubes in the worst case (checkerboard chunk)
-	worstCase := checkerboard(width, height, depth)
-
-	// number of components in each vertex attribute
-	var aPositionSize int32 = 3
-	var aTexCoordsSize int32 = 2
-	totalComponents := aPositionSize + aTexCoordsSize
-
-	// worstCase * 6 sides * 4 vertices * 5 components (xyzst) * 4 bytes
-	vboSize := int(worstCase * 6 * 4 * 5 * 4)
-	// worstCase * 6 sides * 2 triangles * 3 vertex indices * 4 bytes
-	eboSize := int(worstCase * 6 * 2 * 3 * 4)
-
-	var vbo, ebo, vao uint32
+	// initial capacity of the shared chunk arena, it grows on demand
+	ARENA_VERTEX_CAPACITY = 1 << 20
+	ARENA_INDEX_CAPACITY  = ARENA_VERTEX_CAPACITY * 3 / 2
+)
 
-	gl.GenBuffers(1, &vbo)
-	gl.GenBuffers(1, &ebo)
-	gl.GenVertexArrays(1, &vao)
+// Sets the attribute pointers for chunk vertices on the bound VAO, reading
+// from the bound ARRAY_BUFFER.
+func setupChunkVertexLayout() {
+	gl.EnableVertexAttribArray(0) // position
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, CHUNK_VERTEX_SIZE, nil)
 
-	gl.BindVertexArray(vao)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, vboSize, nil, gl.DYNAMIC_DRAW)
+	gl.EnableVertexAttribArray(1) // tex coords + layer
+	gl.VertexAttribPointerWithOffset(1, 3, gl.FLOAT, false, CHUNK_VERTEX_SIZE, scene.VERTEX_TEX_COORD*4)
 
-	gl.EnableVertexAttribArray(0) // position
-	gl.VertexAttribPointer(0, aPositionSize, gl.FLOAT, false, totalComponents*4, nil)
+	gl.EnableVertexAttribArray(2) // sky light + block light + ambient occlusion
+	gl.VertexAttribPointerWithOffset(2, 3, gl.FLOAT, false, CHUNK_VERTEX_SIZE, scene.VERTEX_LIGHT*4)
 
-	gl.EnableVertexAttribArray(1) // tex coords
-	gl.VertexAttribPointerWithOffset(1, aTexCoordsSize, gl.FLOAT, false, totalComponents*4, 3*4)
+	gl.EnableVertexAttribArray(3) // emission
+	gl.VertexAttribPointerWithOffset(3, 1, gl.FLOAT, false, CHUNK_VERTEX_SIZE, scene.VERTEX_EMISSION*4)
 
 	// gl.EnableVertexAttribArray(2) // normal
 	// gl.VertexAttribPointerWithOffset(2, 3, gl.FLOAT, false, 8, 5)
+}
 
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, eboSize, nil, gl.DYNAMIC_DRAW)
-	gl.BindVertexArray(0)
+// WorldRenderer draws the opaque chunk meshes, lit by the sky of the frame.
+type WorldRenderer struct {
+	Shader ShaderProgram
+	Arena  *ChunkArena
+	// block texture array sampled by the chunks
+	Texture uint32
+	// number of chunks drawn in the last frame
+	DrawCount int
+	// multiplier for the sky light of every block, see scene.SkyState
+	SkyLight float32
+	// colour of the fog, which should match the sky horizon
+	FogColor mgl32.Vec3
+	// distance at which the fog fully hides the world; no fog if 0
+	FogDistance float32
+	// cascaded shadows cast by the sun or the moon; no shadows if nil
+	Shadows *ShadowMap
+	// direction towards the light casting shadows, see scene.SkyState
+	LightDirection mgl32.Vec3
+	// how much of the sky light shadows block, from 0 to 1
+	ShadowStrength float32
+}
 
-	var elementCount int32 = 0 // int32(len(indices))
+// Distance covered by the shadows when there is no fog.
+const DEFAULT_SHADOW_DISTANCE = 128
 
-	return ChunkMesh{mgl32.Ident4(), vboSize, eboSize, vbo, ebo, vao, elementCount}
+// World space bounds of the chunk with the given key.
+func chunkBounds(key [3]int) (mgl32.Vec3, mgl32.Vec3) {
+	min := mgl32.Vec3{float32(key[0]), float32(key[1]), float32(key[2])}
+	max := min.Add(mgl32.Vec3{world.CHUNK_SIZE, world.CHUNK_SIZE, world.CHUNK_SIZE})
+	return min, max
 }
 
-type WorldRenderer struct {
-	Shader      ShaderProgram
-	ChunkMeshes []ChunkMesh
-}
+// Fog left at FogDistance, low enough to be indistinguishable from the sky.
+const FOG_CUTOFF = 0.02
 
-func (self *WorldRenderer) CompileShaders() error {
-	vs, err := NewShader(ChunkVsSource, gl.VERTEX_SHADER)
-	if err != nil {
-		return err
+// Density of the squared exponential fog, so that exp(-(d*density)^2) drops
+// to FOG_CUTOFF at the given distance.
+func fogDensity(distance float32) float32 {
+	if distance <= 0 {
+		return 0
 	}
-	defer gl.DeleteShader(vs)
+	return float32(math.Sqrt(-math.Log(FOG_CUTOFF))) / distance
+}
+
+func (self *WorldRenderer) CompileShaders(assets fs.FS) error {
+	var err error
+	self.Shader, err = LoadShaderProgram(assets, "shaders/chunk.vert", "shaders/chunk.frag")
+	return err
+}
 
-	fs, err := NewShader(ChunkFsSource, gl.FRAGMENT_SHADER)
-	if err != nil {
-		return err
+// Meshes every loaded chunk into the shared arena, in world space.
+func (self *WorldRenderer) BuildChunkMeshes(w *world.World, layers scene.BlockLayers) error {
+	if self.Arena == nil {
+		self.Arena = NewChunkArena(ARENA_VERTEX_CAPACITY, ARENA_INDEX_CAPACITY)
 	}
-	defer gl.DeleteShader(fs)
 
-	self.Shader, err = NewShaderProgram(vs, fs)
-	if err != nil {
-		return err
+	for key := range w.Chunks {
+		err := self.BuildChunk(w, key, layers)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func BuildChunkMesh(m *ChunkMesh, x, y, z int, wrld *world.World, blockRepo *BlockRepo) error {
-	chunk := wrld.Chunks[[3]int{x, y, z}]
+// Remeshes a single chunk, keyed like World.Chunks, replacing its geometry in
+// the arena.
+func (self *WorldRenderer) BuildChunk(w *world.World, key [3]int, layers scene.BlockLayers) error {
+	chunk := w.Chunks[key]
 	if chunk == nil {
-		return fmt.Errorf("Chunk not loaded: %d %d %d", x, y, z)
-	}
-	// ox, oy, oz := float32(x<<world.CHUNK_SHIFT), float32(y<<world.CHUNK_SHIFT), float32(z<<world.CHUNK_SHIFT)
-	ox, oy, oz := float32(0.0), float32(0.0), float32(0.0)
-	w, h, d := int(chunk.Width), int(chunk.Height), int(chunk.Depth)
-
-	dir := [3][2][3]float32{} // perpendicular vectors for each axis (for vertex building)
-	for i := 0; i < 3; i++ {
-		dir[i][0][(i+1)%3] = 1.0
-		dir[i][1][(i+2)%3] = 1.0
+		self.Arena.Remove(key)
+		return fmt.Errorf("Chunk not loaded: %d %d %d", key[0], key[1], key[2])
 	}
 
-	vertices := make([]float32, 0, m.VboSize)
-	indices := make([]uint32, 0, m.EboSize)
-
-	cb := [3]bool{} // for each axis, is the current block within chunk bounds
-	nb := [3]bool{} // for each axis, is the next block on that axis within chunk bounds
-
-	cb[2] = false
-	nb[2] = true
-	for k := -1; k < d; k++ {
-		nb[2] = k < d-1
-
-		cb[1] = false
-		nb[1] = true
-		for j := -1; j < h; j++ {
-			nb[1] = j < h-1
-
-			cb[0] = false
-			nb[0] = true
-			for i := -1; i < w; i++ {
-				nb[0] = i < w-1
-
-				var c world.BlockId // current block
-				if cb[0] && cb[1] && cb[2] {
-					c = chunk.BlockAt(uint(i), uint(j), uint(k))
-				}
-
-				n := [3]world.BlockId{} // neighbours for each axis
-				if nb[0] && cb[1] && cb[2] {
-					n[0] = chunk.BlockAt(uint(i)+1, uint(j), uint(k))
-				}
-				if cb[0] && nb[1] && cb[2] {
-					n[1] = chunk.BlockAt(uint(i), uint(j)+1, uint(k))
-				}
-				if cb[0] && cb[1] && nb[2] {
-					n[2] = chunk.BlockAt(uint(i), uint(j), uint(k)+1)
-				}
-
-				for di := 0; di < 3; di++ {
-					if (c != 0) != (n[di] != 0) {
-						var s int // winding order
-						if c == 0 {
-							s = 1
-						}
-
-						t := [3]float32{float32(i), float32(j), float32(k)}
-						u := dir[di][s]
-						v := dir[di][s^1]
-						t[di]++
-
-						ui := uint32(len(vertices)/5)
-						vertices = append(vertices,
-							ox+t[0], oy+t[1], oz+t[2], 0.0, 0.0,
-							ox+t[0]+u[0], oy+t[1]+u[1], oz+t[2]+u[2], 1.0, 0.0,
-							ox+t[0]+u[0]+v[0], oy+t[1]+u[1]+v[1], oz+t[2]+u[2]+v[2], 1.0, 1.0,
-							ox+t[0]+v[0], oy+t[1]+v[1], oz+t[2]+v[2], 0.0, 1.0,
-						)
-
-						indices = append(indices,
-							ui+0, ui+1, ui+2,
-							ui+0, ui+2, ui+3,
-						)
-					}
-				}
-
-				cb[0] = true
-			}
-
-			cb[1] = true
-		}
+	origin := [3]float32{float32(key[0]), float32(key[1]), float32(key[2])}
+	vertices, indices := scene.MeshChunk(chunk, origin, layers, scene.WorldLightSampler(w, key))
+	return self.Arena.Upload(key, vertices, indices)
+}
 
-		cb[2] = true
+// Remeshes the chunks that changed since the last call, see
+// world.World.TakeDirtyChunks.
+func (self *WorldRenderer) BuildDirtyChunks(w *world.World, layers scene.BlockLayers) error {
+	for _, key := range w.TakeDirtyChunks() {
+		err := self.BuildChunk(w, key, layers)
+		if err != nil {
+			return err
+		}
 	}
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, m.Vbo)
-	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(vertices)*4, gl.Ptr(vertices))
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.Ebo)
-	gl.BufferSubData(gl.ELEMENT_ARRAY_BUFFER, 0, len(indices)*4, gl.Ptr(indices))
-	m.ElementCount = int32(len(indices))
-	println("BuildChunkMesh: ElementCount =", m.ElementCount)
-
 	return nil
 }
 
-func (self *WorldRenderer) BuildChunkMeshes(w *world.World, blockRepo *BlockRepo) {
-	self.ChunkMeshes = make([]ChunkMesh, 0, len(w.Chunks))
-	for key := range w.Chunks {
-		println("building chunk", key[0], key[1], key[2])
-		mesh := NewChunkMesh(world.CHUNK_SIZE, world.CHUNK_SIZE, world.CHUNK_SIZE)
-		BuildChunkMesh(&mesh, key[0], key[1], key[2], w, blockRepo)
-		self.ChunkMeshes = append(self.ChunkMeshes, mesh)
-	}
+func (self *WorldRenderer) Stage() RenderStage {
+	return STAGE_WORLD_OPAQUE
+}
+
+// Prepare takes the lighting from the sky of the frame and draws the shadow
+// cascades.
+func (self *WorldRenderer) Prepare(frame *FrameContext) {
+	self.SkyLight = frame.Sky.SkyLight
+	self.FogColor = frame.Sky.Horizon
+	self.LightDirection = frame.Sky.LightDirection
+	self.ShadowStrength = frame.Sky.ShadowStrength
+	self.RenderShadows(frame.View, frame.Fovy, frame.Aspect)
 }
 
-func (self *WorldRenderer) Render(projection, view mgl32.Mat4) {
+// Render draws every chunk in the arena that intersects the view frustum,
+// counting them in DrawCount.
+func (self *WorldRenderer) Render(frame *FrameContext) {
+	self.DrawCount = 0
+	if self.Arena == nil {
+		return
+	}
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, self.Texture)
+
+	// projection and view come from the camera uniform buffer
 	self.Shader.UseProgram()
-	self.Shader.SetUniformMatrix4fv("uProjection", projection)
-	self.Shader.SetUniformMatrix4fv("uView", view)
-	for _, mesh := range self.ChunkMeshes {
-		gl.BindVertexArray(mesh.Vao)
-		gl.DrawElements(gl.TRIANGLES, mesh.ElementCount, gl.UNSIGNED_INT, nil)
+	self.Shader.SetUniformMatrix4fv("uModel", mgl32.Ident4())
+	self.Shader.SetUniform1f("uSkyLight", self.SkyLight)
+	self.Shader.SetUniform3fv("uFogColor", self.FogColor)
+	self.Shader.SetUniform1f("uFogDensity", fogDensity(self.FogDistance))
+
+	if self.Shadows != nil {
+		self.Shadows.Bind(self.Shader, self.ShadowStrength)
+	} else {
+		self.Shader.SetUniform1f("uShadowStrength", 0)
+	}
+
+	frustum := NewFrustum(frame.Projection.Mul4(frame.View))
+	self.DrawCount = self.Arena.Draw(func(key [3]int) bool {
+		return frustum.IntersectsAABB(chunkBounds(key))
+	})
+}
+
+// RenderShadows updates the shadow cascades for the camera and draws the
+// chunks into them. It must be called before Render.
+func (self *WorldRenderer) RenderShadows(view mgl32.Mat4, fovy, aspect float32) {
+	if self.Arena == nil || self.Shadows == nil || self.ShadowStrength <= 0 {
+		return
+	}
+
+	distance := self.FogDistance
+	if distance <= 0 {
+		distance = DEFAULT_SHADOW_DISTANCE
 	}
+	self.Shadows.Update(view, fovy, aspect, distance, self.LightDirection)
+	self.Shadows.Render(self.Arena)
 }
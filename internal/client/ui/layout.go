@@ -0,0 +1,226 @@
+package ui
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Direction is the axis a Box stacks its children along.
+type Direction int
+
+const (
+	VERTICAL Direction = iota
+	HORIZONTAL
+)
+
+// Align places children across the axis of a Box.
+type Align int
+
+const (
+	ALIGN_START Align = iota
+	ALIGN_CENTER
+	ALIGN_END
+	// children take the full width of a vertical box, or height of a
+	// horizontal one
+	ALIGN_STRETCH
+)
+
+// Box stacks its children in a row or column, each at its preferred size
+// along the axis.
+type Box struct {
+	base
+	Direction Direction
+	Align     Align
+	// space around the children, in UI units
+	Padding float32
+	// space between the children, in UI units
+	Spacing  float32
+	children []Widget
+	// drawn behind the children if not transparent
+	Background mgl32.Vec4
+}
+
+// NewVBox creates a column of stretched children, with the spacing of the
+// default theme.
+func NewVBox(children ...Widget) *Box {
+	return &Box{
+		Direction: VERTICAL,
+		Align:     ALIGN_STRETCH,
+		Spacing:   DefaultTheme().Spacing,
+		children:  children,
+	}
+}
+
+// NewHBox creates a row of vertically centered children, with the spacing of
+// the default theme.
+func NewHBox(children ...Widget) *Box {
+	return &Box{
+		Direction: HORIZONTAL,
+		Align:     ALIGN_CENTER,
+		Spacing:   DefaultTheme().Spacing,
+		children:  children,
+	}
+}
+
+// NewPanel creates a padded column over the panel background of the default
+// theme.
+func NewPanel(children ...Widget) *Box {
+	theme := DefaultTheme()
+	box := NewVBox(children...)
+	box.Padding = 2 * theme.Padding
+	box.Background = theme.Panel
+	return box
+}
+
+func (self *Box) Add(children ...Widget) {
+	self.children = append(self.children, children...)
+}
+
+// Clear removes every child.
+func (self *Box) Clear() {
+	self.children = nil
+}
+
+func (self *Box) Children() []Widget {
+	return self.children
+}
+
+// Splits a size into the components along and across the axis of the box,
+// or back.
+func (self *Box) axes(width, height float32) (float32, float32) {
+	if self.Direction == HORIZONTAL {
+		return width, height
+	}
+	return height, width
+}
+
+func (self *Box) Measure(root *Root) (float32, float32) {
+	main, cross := float32(0), float32(0)
+	for i, child := range self.children {
+		childMain, childCross := self.axes(child.Measure(root))
+		main += childMain
+		if i > 0 {
+			main += self.Spacing
+		}
+		cross = maxf(cross, childCross)
+	}
+	main += 2 * self.Padding
+	cross += 2 * self.Padding
+	return self.axes(main, cross)
+}
+
+func (self *Box) Layout(root *Root, bounds Rect) {
+	self.bounds = bounds
+	inner := bounds.Inset(self.Padding)
+	_, crossSize := self.axes(inner.W, inner.H)
+
+	offset := float32(0)
+	for _, child := range self.children {
+		childMain, childCross := self.axes(child.Measure(root))
+
+		crossOffset := float32(0)
+		switch self.Align {
+		case ALIGN_CENTER:
+			crossOffset = (crossSize - childCross) / 2
+		case ALIGN_END:
+			crossOffset = crossSize - childCross
+		case ALIGN_STRETCH:
+			childCross = crossSize
+		}
+
+		if self.Direction == HORIZONTAL {
+			child.Layout(root, Rect{inner.X + offset, inner.Y + crossOffset, childMain, childCross})
+		} else {
+			child.Layout(root, Rect{inner.X + crossOffset, inner.Y + offset, childCross, childMain})
+		}
+		offset += childMain + self.Spacing
+	}
+}
+
+func (self *Box) Paint(root *Root, canvas *Canvas) {
+	if self.Background[3] > 0 {
+		canvas.FillRect(self.bounds, self.Background)
+	}
+	for _, child := range self.children {
+		child.Paint(root, canvas)
+	}
+}
+
+// Center places its child in the middle of its bounds, at the child's
+// preferred size.
+type Center struct {
+	base
+	Child Widget
+}
+
+func NewCenter(child Widget) *Center {
+	return &Center{Child: child}
+}
+
+func (self *Center) Children() []Widget {
+	return []Widget{self.Child}
+}
+
+func (self *Center) Measure(root *Root) (float32, float32) {
+	return self.Child.Measure(root)
+}
+
+func (self *Center) Layout(root *Root, bounds Rect) {
+	self.bounds = bounds
+	width, height := self.Child.Measure(root)
+	width, height = minf(width, bounds.W), minf(height, bounds.H)
+	self.Child.Layout(root, Rect{
+		bounds.X + (bounds.W-width)/2,
+		bounds.Y + (bounds.H-height)/2,
+		width,
+		height,
+	})
+}
+
+func (self *Center) Paint(root *Root, canvas *Canvas) {
+	self.Child.Paint(root, canvas)
+}
+
+// Sized overrides the preferred size of its child. A zero width or height
+// keeps the child's own; a nil child makes an empty spacer.
+type Sized struct {
+	base
+	Width  float32
+	Height float32
+	Child  Widget
+}
+
+func NewSized(width, height float32, child Widget) *Sized {
+	return &Sized{Width: width, Height: height, Child: child}
+}
+
+func (self *Sized) Children() []Widget {
+	if self.Child == nil {
+		return nil
+	}
+	return []Widget{self.Child}
+}
+
+func (self *Sized) Measure(root *Root) (float32, float32) {
+	width, height := float32(0), float32(0)
+	if self.Child != nil {
+		width, height = self.Child.Measure(root)
+	}
+	if self.Width > 0 {
+		width = self.Width
+	}
+	if self.Height > 0 {
+		height = self.Height
+	}
+	return width, height
+}
+
+func (self *Sized) Layout(root *Root, bounds Rect) {
+	self.bounds = bounds
+	if self.Child != nil {
+		self.Child.Layout(root, bounds)
+	}
+}
+
+func (self *Sized) Paint(root *Root, canvas *Canvas) {
+	if self.Child != nil {
+		self.Child.Paint(root, canvas)
+	}
+}
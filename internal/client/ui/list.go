@@ -0,0 +1,178 @@
+package ui
+
+// List is a scrollable list of text rows, one of which can be selected. A
+// row is selected by clicking it or with the arrow keys, and activated by
+// double clicking it or pressing Enter.
+type List struct {
+	base
+	Items []string
+	// index of the selected row, -1 if none
+	Selected int
+	// number of rows shown without scrolling, for the preferred height
+	Rows       int
+	OnSelect   func(index int)
+	OnActivate func(index int)
+
+	// scrolled distance from the first row, in UI units
+	scroll float32
+}
+
+func NewList(items []string) *List {
+	return &List{Items: items, Selected: -1, Rows: 6}
+}
+
+// SetItems replaces the rows, keeping the selection if it is still in
+// range.
+func (self *List) SetItems(items []string) {
+	self.Items = items
+	if self.Selected >= len(items) {
+		self.Selected = -1
+	}
+	self.scroll = 0
+}
+
+func (self *List) CanFocus() bool {
+	return true
+}
+
+func (self *List) rowHeight(root *Root) float32 {
+	return root.LineHeight() + root.Theme.Padding
+}
+
+func (self *List) Measure(root *Root) (float32, float32) {
+	width := float32(FIELD_WIDTH)
+	for _, item := range self.Items {
+		itemWidth, _ := root.MeasureText(item)
+		width = maxf(width, itemWidth+2*root.Theme.Padding)
+	}
+	return width, float32(self.Rows) * self.rowHeight(root)
+}
+
+// Keeps the scroll within the rows.
+func (self *List) clampScroll(root *Root) {
+	overflow := float32(len(self.Items))*self.rowHeight(root) - self.bounds.H
+	self.scroll = clampf(self.scroll, 0, maxf(0, overflow))
+}
+
+// RowAt returns the index of the row at a position inside the list, or -1.
+func (self *List) RowAt(root *Root, x, y float32) int {
+	if !self.bounds.Contains(x, y) {
+		return -1
+	}
+	index := int((y - self.bounds.Y + self.scroll) / self.rowHeight(root))
+	if index < 0 || index >= len(self.Items) {
+		return -1
+	}
+	return index
+}
+
+// Select changes the selection, scrolls the row into view and calls
+// OnSelect.
+func (self *List) Select(root *Root, index int) {
+	if index < 0 || index >= len(self.Items) {
+		return
+	}
+
+	rowHeight := self.rowHeight(root)
+	top := float32(index) * rowHeight
+	if top < self.scroll {
+		self.scroll = top
+	} else if top+rowHeight > self.scroll+self.bounds.H {
+		self.scroll = top + rowHeight - self.bounds.H
+	}
+	self.clampScroll(root)
+
+	if index == self.Selected {
+		return
+	}
+	self.Selected = index
+	if self.OnSelect != nil {
+		self.OnSelect(index)
+	}
+}
+
+func (self *List) activate() {
+	if self.Selected >= 0 && self.OnActivate != nil {
+		self.OnActivate(self.Selected)
+	}
+}
+
+func (self *List) HandleEvent(root *Root, event Event) bool {
+	switch event.Kind {
+	case EVENT_MOUSE_DOWN:
+		index := self.RowAt(root, event.X, event.Y)
+		if index >= 0 {
+			self.Select(root, index)
+			if event.Clicks >= 2 {
+				self.activate()
+			}
+		}
+		return true
+	case EVENT_MOUSE_UP:
+		return true
+	case EVENT_SCROLL:
+		self.scroll -= event.Scroll * self.rowHeight(root)
+		self.clampScroll(root)
+		return true
+	case EVENT_KEY:
+		switch event.Key {
+		case KEY_UP:
+			if self.Selected < 0 {
+				self.Select(root, len(self.Items)-1)
+			} else {
+				self.Select(root, self.Selected-1)
+			}
+		case KEY_DOWN:
+			self.Select(root, self.Selected+1)
+		case KEY_HOME:
+			self.Select(root, 0)
+		case KEY_END:
+			self.Select(root, len(self.Items)-1)
+		case KEY_ENTER:
+			self.activate()
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func (self *List) Layout(root *Root, bounds Rect) {
+	self.bounds = bounds
+	self.clampScroll(root)
+}
+
+func (self *List) Paint(root *Root, canvas *Canvas) {
+	theme := root.Theme
+	canvas.FillRect(self.bounds, theme.Panel)
+
+	rowHeight := self.rowHeight(root)
+	canvas.PushClip(self.bounds)
+	first := int(self.scroll / rowHeight)
+	for i := first; i < len(self.Items); i++ {
+		row := Rect{self.bounds.X, self.bounds.Y + float32(i)*rowHeight - self.scroll, self.bounds.W, rowHeight}
+		if row.Y >= self.bounds.Y+self.bounds.H {
+			break
+		}
+		if i == self.Selected {
+			canvas.FillRect(row, theme.Accent)
+		}
+		_, height := root.MeasureText(self.Items[i])
+		canvas.Text(self.Items[i], row.X+theme.Padding, row.Y+(row.H-height)/2, theme.Text)
+	}
+
+	// scrollbar, when the rows don't fit
+	total := float32(len(self.Items)) * rowHeight
+	if total > self.bounds.H {
+		width := theme.Padding
+		height := self.bounds.H * self.bounds.H / total
+		y := self.bounds.Y + self.scroll/total*self.bounds.H
+		canvas.FillRect(Rect{self.bounds.X + self.bounds.W - width, y, width, height}, theme.WidgetHover)
+	}
+	canvas.PopClip()
+
+	if root.IsFocused(self) {
+		canvas.Outline(self.bounds, theme.Border, theme.Focus)
+	}
+}
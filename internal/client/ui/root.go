@@ -0,0 +1,329 @@
+package ui
+
+// EventKind tells which fields of an Event are set.
+type EventKind int
+
+const (
+	// X, Y
+	EVENT_MOUSE_MOVE EventKind = iota
+	// X, Y, Clicks; only the primary mouse button is reported
+	EVENT_MOUSE_DOWN
+	EVENT_MOUSE_UP
+	// X, Y, Scroll
+	EVENT_SCROLL
+	// Key, Shift
+	EVENT_KEY
+	// Char
+	EVENT_CHAR
+)
+
+// Key is a key the widgets react to, independent of the windowing library.
+type Key int
+
+const (
+	KEY_UNKNOWN Key = iota
+	KEY_TAB
+	KEY_ENTER
+	KEY_ESCAPE
+	KEY_SPACE
+	KEY_BACKSPACE
+	KEY_DELETE
+	KEY_LEFT
+	KEY_RIGHT
+	KEY_UP
+	KEY_DOWN
+	KEY_HOME
+	KEY_END
+)
+
+// Event is an input event. Positions are in pixels when given to the Root,
+// which converts them to UI units before delivering them to widgets.
+type Event struct {
+	Kind EventKind
+	X, Y float32
+	// 2 for the second press of a double click, 1 otherwise
+	Clicks int
+	// scrolled lines, positive away from the user
+	Scroll float32
+	// key pressed or repeated
+	Key   Key
+	Shift bool
+	// character typed
+	Char rune
+}
+
+// Root holds a widget tree, lays it out to fill the screen and routes input
+// to its widgets. It tracks the widget under the mouse, the one holding the
+// keyboard focus and the one capturing the mouse while a button is held.
+type Root struct {
+	Content Widget
+	Theme   Theme
+	Text    TextMeasurer
+	// pixels per UI unit
+	Scale float32
+
+	width    float32
+	height   float32
+	focused  Widget
+	hovered  Widget
+	captured Widget
+}
+
+func NewRoot(content Widget, text TextMeasurer) *Root {
+	return &Root{
+		Content: content,
+		Theme:   DefaultTheme(),
+		Text:    text,
+		Scale:   2,
+	}
+}
+
+// SetContent replaces the widget tree, dropping the focus.
+func (self *Root) SetContent(content Widget) {
+	self.Content = content
+	self.focused, self.hovered, self.captured = nil, nil, nil
+}
+
+// Layout lays the content out over a screen of the given size, in pixels.
+func (self *Root) Layout(width, height float32) {
+	self.width, self.height = width/self.Scale, height/self.Scale
+	if self.Content != nil {
+		self.Content.Layout(self, Rect{0, 0, self.width, self.height})
+	}
+}
+
+// Paint draws the content, as placed by the last Layout.
+func (self *Root) Paint(painter Painter) {
+	if self.Content == nil {
+		return
+	}
+	canvas := Canvas{painter: painter, scale: self.Scale}
+	self.Content.Paint(self, &canvas)
+}
+
+// Size of the screen in UI units, as of the last Layout.
+func (self *Root) Size() (width, height float32) {
+	return self.width, self.height
+}
+
+func (self *Root) MeasureText(text string) (width, height float32) {
+	if self.Text == nil {
+		return 0, 0
+	}
+	return self.Text.Measure(text)
+}
+
+// Height of a line of text, in UI units.
+func (self *Root) LineHeight() float32 {
+	_, height := self.MeasureText("")
+	return height
+}
+
+func (self *Root) Focused() Widget {
+	return self.focused
+}
+
+// Focus gives the keyboard focus to a widget; nil removes it.
+func (self *Root) Focus(widget Widget) {
+	self.focused = widget
+}
+
+func (self *Root) IsFocused(widget Widget) bool {
+	return widget != nil && self.focused == widget
+}
+
+func (self *Root) IsHovered(widget Widget) bool {
+	return widget != nil && self.hovered == widget
+}
+
+// IsPressed returns whether the mouse button went down on a widget and is
+// still held.
+func (self *Root) IsPressed(widget Widget) bool {
+	return widget != nil && self.captured == widget
+}
+
+// WidgetAt returns the innermost widget at x, y in UI units, or nil.
+func (self *Root) WidgetAt(x, y float32) Widget {
+	path := self.pathAt(x, y)
+	if len(path) == 0 {
+		return nil
+	}
+	return path[len(path)-1]
+}
+
+// Widgets containing x, y, from the content down to the innermost one.
+// Children are tested last to first, so the one drawn on top wins.
+func (self *Root) pathAt(x, y float32) []Widget {
+	if self.Content == nil {
+		return nil
+	}
+	return appendPathAt(nil, self.Content, x, y)
+}
+
+func appendPathAt(path []Widget, widget Widget, x, y float32) []Widget {
+	if !widget.Bounds().Contains(x, y) {
+		return path
+	}
+	path = append(path, widget)
+	if container, ok := widget.(Container); ok {
+		children := container.Children()
+		for i := len(children) - 1; i >= 0; i-- {
+			n := len(path)
+			path = appendPathAt(path, children[i], x, y)
+			if len(path) > n {
+				break
+			}
+		}
+	}
+	return path
+}
+
+// Widgets from the content down to target, nil if target isn't in the tree.
+func (self *Root) pathTo(target Widget) []Widget {
+	if self.Content == nil || target == nil {
+		return nil
+	}
+	var find func(path []Widget, widget Widget) []Widget
+	find = func(path []Widget, widget Widget) []Widget {
+		path = append(path, widget)
+		if widget == target {
+			return path
+		}
+		if container, ok := widget.(Container); ok {
+			for _, child := range container.Children() {
+				if found := find(path, child); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	return find(nil, self.Content)
+}
+
+// Focusable widgets in tree order.
+func (self *Root) focusables() []Widget {
+	var widgets []Widget
+	var walk func(widget Widget)
+	walk = func(widget Widget) {
+		if focusable, ok := widget.(Focusable); ok && focusable.CanFocus() {
+			widgets = append(widgets, widget)
+		}
+		if container, ok := widget.(Container); ok {
+			for _, child := range container.Children() {
+				walk(child)
+			}
+		}
+	}
+	if self.Content != nil {
+		walk(self.Content)
+	}
+	return widgets
+}
+
+// FocusNext moves the focus to the next focusable widget, or the previous
+// one if backwards, wrapping around.
+func (self *Root) FocusNext(backwards bool) {
+	widgets := self.focusables()
+	if len(widgets) == 0 {
+		self.focused = nil
+		return
+	}
+
+	current := -1
+	for i, widget := range widgets {
+		if widget == self.focused {
+			current = i
+		}
+	}
+
+	next := 0
+	if backwards {
+		next = len(widgets) - 1
+	}
+	if current >= 0 {
+		step := 1
+		if backwards {
+			step = -1
+		}
+		next = (current + step + len(widgets)) % len(widgets)
+	}
+	self.focused = widgets[next]
+}
+
+// Delivers an event to the widgets of a path, innermost first, until one
+// consumes it. Returns the widget that did, or nil.
+func (self *Root) bubble(path []Widget, event Event) Widget {
+	for i := len(path) - 1; i >= 0; i-- {
+		if handler, ok := path[i].(EventHandler); ok && handler.HandleEvent(self, event) {
+			return path[i]
+		}
+	}
+	return nil
+}
+
+// HandleEvent routes an input event to the widgets and returns whether one
+// of them consumed it. Mouse events go to the widget under the cursor, or
+// to the widget that captured the mouse; key and character events go to the
+// focused widget. Tab moves the focus.
+func (self *Root) HandleEvent(event Event) bool {
+	if self.Content == nil {
+		return false
+	}
+	event.X /= self.Scale
+	event.Y /= self.Scale
+
+	switch event.Kind {
+	case EVENT_MOUSE_MOVE:
+		path := self.pathAt(event.X, event.Y)
+		self.hovered = nil
+		if len(path) > 0 {
+			self.hovered = path[len(path)-1]
+		}
+		if self.captured != nil {
+			return self.bubble([]Widget{self.captured}, event) != nil
+		}
+		return self.bubble(path, event) != nil
+
+	case EVENT_MOUSE_DOWN:
+		path := self.pathAt(event.X, event.Y)
+		self.focused = nil
+		for i := len(path) - 1; i >= 0; i-- {
+			if focusable, ok := path[i].(Focusable); ok && focusable.CanFocus() {
+				self.focused = path[i]
+				break
+			}
+		}
+		self.captured = self.bubble(path, event)
+		return self.captured != nil
+
+	case EVENT_MOUSE_UP:
+		captured := self.captured
+		self.captured = nil
+		if captured != nil {
+			self.bubble([]Widget{captured}, event)
+			return true
+		}
+		return self.bubble(self.pathAt(event.X, event.Y), event) != nil
+
+	case EVENT_SCROLL:
+		return self.bubble(self.pathAt(event.X, event.Y), event) != nil
+
+	case EVENT_KEY:
+		if self.bubble(self.pathTo(self.focused), event) != nil {
+			return true
+		}
+		if event.Key == KEY_TAB {
+			self.FocusNext(event.Shift)
+			return true
+		}
+		return false
+
+	case EVENT_CHAR:
+		if self.focused == nil {
+			return false
+		}
+		return self.bubble([]Widget{self.focused}, event) != nil
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package ui
+
+// TextField edits a single line of text. It takes typed characters while
+// focused, and moves its cursor with the arrow, Home and End keys.
+type TextField struct {
+	base
+	Text string
+	// shown greyed out while the text is empty
+	Placeholder string
+	// maximum number of characters; unlimited if 0
+	MaxLength int
+	// called after every edit
+	OnChange func(text string)
+	// called when Enter is pressed
+	OnSubmit func(text string)
+
+	// cursor position, in characters
+	cursor int
+	// horizontal scroll of the text, in UI units, keeping the cursor visible
+	scroll float32
+}
+
+func NewTextField(placeholder string) *TextField {
+	return &TextField{Placeholder: placeholder}
+}
+
+func (self *TextField) CanFocus() bool {
+	return true
+}
+
+// SetText replaces the text and moves the cursor to its end.
+func (self *TextField) SetText(text string) {
+	self.Text = text
+	self.cursor = len([]rune(text))
+}
+
+func (self *TextField) Measure(root *Root) (float32, float32) {
+	return FIELD_WIDTH, controlHeight(root)
+}
+
+func (self *TextField) edit(runes []rune, cursor int) {
+	self.Text = string(runes)
+	self.cursor = cursor
+	if self.OnChange != nil {
+		self.OnChange(self.Text)
+	}
+}
+
+// Character position closest to a horizontal position in the field.
+func (self *TextField) cursorAt(root *Root, x float32) int {
+	runes := []rune(self.Text)
+	x -= self.bounds.X + root.Theme.Padding - self.scroll
+	previous := float32(0)
+	for i := range runes {
+		width, _ := root.MeasureText(string(runes[:i+1]))
+		if x < (previous+width)/2 {
+			return i
+		}
+		previous = width
+	}
+	return len(runes)
+}
+
+func (self *TextField) HandleEvent(root *Root, event Event) bool {
+	runes := []rune(self.Text)
+	if self.cursor > len(runes) {
+		self.cursor = len(runes)
+	}
+
+	switch event.Kind {
+	case EVENT_MOUSE_DOWN:
+		self.cursor = self.cursorAt(root, event.X)
+		return true
+	case EVENT_MOUSE_UP:
+		return true
+
+	case EVENT_CHAR:
+		if event.Char < ' ' || (self.MaxLength > 0 && len(runes) >= self.MaxLength) {
+			return true
+		}
+		edited := make([]rune, 0, len(runes)+1)
+		edited = append(edited, runes[:self.cursor]...)
+		edited = append(edited, event.Char)
+		edited = append(edited, runes[self.cursor:]...)
+		self.edit(edited, self.cursor+1)
+		return true
+
+	case EVENT_KEY:
+		switch event.Key {
+		case KEY_BACKSPACE:
+			if self.cursor > 0 {
+				self.edit(append(runes[:self.cursor-1:self.cursor-1], runes[self.cursor:]...), self.cursor-1)
+			}
+		case KEY_DELETE:
+			if self.cursor < len(runes) {
+				self.edit(append(runes[:self.cursor:self.cursor], runes[self.cursor+1:]...), self.cursor)
+			}
+		case KEY_LEFT:
+			if self.cursor > 0 {
+				self.cursor--
+			}
+		case KEY_RIGHT:
+			if self.cursor < len(runes) {
+				self.cursor++
+			}
+		case KEY_HOME:
+			self.cursor = 0
+		case KEY_END:
+			self.cursor = len(runes)
+		case KEY_ENTER:
+			if self.OnSubmit != nil {
+				self.OnSubmit(self.Text)
+			}
+		case KEY_SPACE:
+			// typed as a character
+		default:
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func (self *TextField) Paint(root *Root, canvas *Canvas) {
+	theme := root.Theme
+	focused := root.IsFocused(self)
+	canvas.FillRect(self.bounds, theme.WidgetPress)
+	if focused {
+		canvas.Outline(self.bounds, theme.Border, theme.Focus)
+	}
+
+	inner := self.bounds.Inset(theme.Padding)
+	runes := []rune(self.Text)
+	if self.cursor > len(runes) {
+		self.cursor = len(runes)
+	}
+
+	// scroll just enough for the cursor to stay inside the field
+	cursorX, _ := root.MeasureText(string(runes[:self.cursor]))
+	if cursorX-self.scroll > inner.W-theme.Border {
+		self.scroll = cursorX - inner.W + theme.Border
+	} else if cursorX < self.scroll {
+		self.scroll = cursorX
+	}
+
+	_, height := root.MeasureText(self.Text)
+	y := inner.Y + (inner.H-height)/2
+	canvas.PushClip(inner)
+	if len(runes) == 0 {
+		canvas.Text(self.Placeholder, inner.X, y, theme.TextDisabled)
+	} else {
+		canvas.Text(self.Text, inner.X-self.scroll, y, theme.Text)
+	}
+	if focused {
+		canvas.FillRect(Rect{inner.X + cursorX - self.scroll, y, theme.Border, height}, theme.Accent)
+	}
+	canvas.PopClip()
+}
@@ -0,0 +1,198 @@
+// Package ui is a retained-mode widget toolkit for menus. Widgets are laid
+// out in UI units, which the Root maps to pixels by its scale factor, and
+// paint themselves through a Painter, so layout, hit-testing and input
+// handling don't depend on OpenGL.
+package ui
+
+import "github.com/go-gl/mathgl/mgl32"
+
+// Rect is an axis aligned rectangle with the origin at its top-left corner,
+// y pointing down.
+type Rect struct {
+	X, Y, W, H float32
+}
+
+func (self Rect) Contains(x, y float32) bool {
+	return x >= self.X && x < self.X+self.W && y >= self.Y && y < self.Y+self.H
+}
+
+// Inset shrinks the rectangle by d on every side.
+func (self Rect) Inset(d float32) Rect {
+	return Rect{self.X + d, self.Y + d, maxf(0, self.W-2*d), maxf(0, self.H-2*d)}
+}
+
+// Intersect returns the overlap of two rectangles, empty if they are
+// disjoint.
+func (self Rect) Intersect(other Rect) Rect {
+	x0, y0 := maxf(self.X, other.X), maxf(self.Y, other.Y)
+	x1, y1 := minf(self.X+self.W, other.X+other.W), minf(self.Y+self.H, other.Y+other.H)
+	return Rect{x0, y0, maxf(0, x1-x0), maxf(0, y1-y0)}
+}
+
+// Scale multiplies the position and size of the rectangle by s.
+func (self Rect) Scale(s float32) Rect {
+	return Rect{self.X * s, self.Y * s, self.W * s, self.H * s}
+}
+
+func minf(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clampf(n, lo, hi float32) float32 {
+	return maxf(lo, minf(n, hi))
+}
+
+// Widget is an element of the interface. Widgets are placed by their parent:
+// Measure reports the size a widget would like, Layout gives it its final
+// bounds.
+type Widget interface {
+	// preferred size, in UI units
+	Measure(root *Root) (width, height float32)
+	// places the widget and its children inside bounds, in UI units
+	Layout(root *Root, bounds Rect)
+	Bounds() Rect
+	Paint(root *Root, canvas *Canvas)
+}
+
+// Container is implemented by widgets with children, for hit-testing and
+// focus traversal.
+type Container interface {
+	Children() []Widget
+}
+
+// EventHandler is implemented by widgets reacting to input. HandleEvent
+// returns whether the event was consumed; events that aren't bubble up to
+// the parent widgets.
+type EventHandler interface {
+	HandleEvent(root *Root, event Event) bool
+}
+
+// Focusable is implemented by widgets that can take the keyboard focus.
+type Focusable interface {
+	CanFocus() bool
+}
+
+// Common state of the widgets, embedded by them.
+type base struct {
+	bounds Rect
+}
+
+func (self *base) Bounds() Rect {
+	return self.bounds
+}
+
+func (self *base) Layout(root *Root, bounds Rect) {
+	self.bounds = bounds
+}
+
+// Theme holds the colours and spacing shared by every widget.
+type Theme struct {
+	Text         mgl32.Vec4
+	TextDisabled mgl32.Vec4
+	// background of panels and lists
+	Panel mgl32.Vec4
+	// background of buttons, sliders, checkboxes and text fields
+	Widget      mgl32.Vec4
+	WidgetHover mgl32.Vec4
+	WidgetPress mgl32.Vec4
+	// slider handles, checkmarks, selected list rows and the text cursor
+	Accent mgl32.Vec4
+	// outline of the focused widget
+	Focus mgl32.Vec4
+	// space between the border of a widget and its content
+	Padding float32
+	// space between the children of a box
+	Spacing float32
+	// width of the focus outline
+	Border float32
+}
+
+func DefaultTheme() Theme {
+	return Theme{
+		Text:         mgl32.Vec4{1, 1, 1, 1},
+		TextDisabled: mgl32.Vec4{0.6, 0.6, 0.6, 1},
+		Panel:        mgl32.Vec4{0, 0, 0, 0.5},
+		Widget:       mgl32.Vec4{0.25, 0.25, 0.3, 0.9},
+		WidgetHover:  mgl32.Vec4{0.35, 0.35, 0.45, 0.9},
+		WidgetPress:  mgl32.Vec4{0.2, 0.2, 0.25, 0.9},
+		Accent:       mgl32.Vec4{0.4, 0.4, 0.8, 1},
+		Focus:        mgl32.Vec4{0.9, 0.9, 1, 1},
+		Padding:      4,
+		Spacing:      4,
+		Border:       1,
+	}
+}
+
+// TextMeasurer measures text in UI units, as it would be drawn by the
+// Painter with a scale of 1.
+type TextMeasurer interface {
+	Measure(text string) (width, height float32)
+}
+
+// Painter draws the interface, in pixels from the top-left corner of the
+// screen. It is implemented by the renderer.
+type Painter interface {
+	FillRect(rect Rect, color mgl32.Vec4)
+	// draws text with its top-left corner at x, y, scale pixels per UI unit
+	DrawText(text string, x, y, scale float32, color mgl32.Vec4)
+	// restricts drawing to a rectangle; nil lifts the restriction
+	SetClip(clip *Rect)
+}
+
+// Canvas is what widgets paint with: it takes UI units and forwards them to
+// the Painter in pixels, and keeps a stack of clip rectangles.
+type Canvas struct {
+	painter Painter
+	scale   float32
+	clips   []Rect
+}
+
+func (self *Canvas) FillRect(rect Rect, color mgl32.Vec4) {
+	if rect.W <= 0 || rect.H <= 0 {
+		return
+	}
+	self.painter.FillRect(rect.Scale(self.scale), color)
+}
+
+// Outline draws the border of a rectangle, width units wide, inside it.
+func (self *Canvas) Outline(rect Rect, width float32, color mgl32.Vec4) {
+	self.FillRect(Rect{rect.X, rect.Y, rect.W, width}, color)
+	self.FillRect(Rect{rect.X, rect.Y + rect.H - width, rect.W, width}, color)
+	self.FillRect(Rect{rect.X, rect.Y + width, width, rect.H - 2*width}, color)
+	self.FillRect(Rect{rect.X + rect.W - width, rect.Y + width, width, rect.H - 2*width}, color)
+}
+
+func (self *Canvas) Text(text string, x, y float32, color mgl32.Vec4) {
+	self.painter.DrawText(text, x*self.scale, y*self.scale, self.scale, color)
+}
+
+// PushClip restricts drawing to the intersection of rect and the current
+// clip, until the matching PopClip.
+func (self *Canvas) PushClip(rect Rect) {
+	if len(self.clips) > 0 {
+		rect = rect.Intersect(self.clips[len(self.clips)-1])
+	}
+	self.clips = append(self.clips, rect)
+	clip := rect.Scale(self.scale)
+	self.painter.SetClip(&clip)
+}
+
+func (self *Canvas) PopClip() {
+	self.clips = self.clips[:len(self.clips)-1]
+	if len(self.clips) == 0 {
+		self.painter.SetClip(nil)
+		return
+	}
+	clip := self.clips[len(self.clips)-1].Scale(self.scale)
+	self.painter.SetClip(&clip)
+}
@@ -0,0 +1,138 @@
+package ui
+
+import "testing"
+
+// Measures text as a fixed width font, 6 by 10 units per character.
+type fixedText struct{}
+
+func (fixedText) Measure(text string) (float32, float32) {
+	return float32(6 * len(text)), 10
+}
+
+func newTestRoot(content Widget) *Root {
+	root := NewRoot(content, fixedText{})
+	root.Scale = 1
+	return root
+}
+
+func TestBoxLayout(t *testing.T) {
+	a, b := NewSized(10, 20, nil), NewSized(30, 10, nil)
+	column := NewVBox(a, b)
+	column.Padding = 2
+	column.Spacing = 4
+	column.Layout(newTestRoot(column), Rect{0, 0, 100, 100})
+
+	if got, want := a.Bounds(), (Rect{2, 2, 96, 20}); got != want {
+		t.Errorf("first child of a stretched column at %v, want %v", got, want)
+	}
+	if got, want := b.Bounds(), (Rect{2, 26, 96, 10}); got != want {
+		t.Errorf("second child of a stretched column at %v, want %v", got, want)
+	}
+
+	row := NewHBox(a, b)
+	row.Padding = 2
+	row.Spacing = 4
+	row.Layout(newTestRoot(row), Rect{0, 0, 100, 100})
+
+	if got, want := a.Bounds(), (Rect{2, 40, 10, 20}); got != want {
+		t.Errorf("first child of a centered row at %v, want %v", got, want)
+	}
+	if got, want := b.Bounds(), (Rect{16, 45, 30, 10}); got != want {
+		t.Errorf("second child of a centered row at %v, want %v", got, want)
+	}
+
+	if width, height := row.Measure(newTestRoot(row)); width != 48 || height != 24 {
+		t.Errorf("row measures %gx%g, want 48x24", width, height)
+	}
+}
+
+func TestWidgetAt(t *testing.T) {
+	below, above := NewSized(0, 0, nil), NewSized(0, 0, nil)
+	box := NewVBox(below, above)
+	root := newTestRoot(box)
+	root.Layout(100, 100)
+	// overlapping, the last child drawn on top
+	below.Layout(root, Rect{0, 0, 50, 50})
+	above.Layout(root, Rect{25, 25, 50, 50})
+
+	cases := []struct {
+		x, y float32
+		want Widget
+	}{
+		{10, 10, below},
+		{30, 30, above},
+		{90, 90, box},
+	}
+	for _, c := range cases {
+		if got := root.WidgetAt(c.x, c.y); got != c.want {
+			t.Errorf("WidgetAt(%g, %g) = %p, want %p", c.x, c.y, got, c.want)
+		}
+	}
+
+	path := root.pathAt(30, 30)
+	if len(path) != 2 || path[0] != box || path[1] != above {
+		t.Errorf("pathAt(30, 30) = %v, want the box then the child on top", path)
+	}
+	if got := root.WidgetAt(150, 10); got != nil {
+		t.Errorf("WidgetAt outside the screen = %p, want nil", got)
+	}
+}
+
+func TestFocusNextWraps(t *testing.T) {
+	a, b := NewButton("a", nil), NewButton("b", nil)
+	c := NewCheckbox("c", false, nil)
+	root := newTestRoot(NewPanel(a, NewLabel("not focusable"), NewVBox(b), c))
+
+	order := []Widget{a, b, c, a}
+	for i, want := range order {
+		root.FocusNext(false)
+		if root.Focused() != want {
+			t.Fatalf("forward step %d focused %p, want %p", i, root.Focused(), want)
+		}
+	}
+
+	root.FocusNext(true)
+	if root.Focused() != c {
+		t.Fatalf("backwards from the first widget focused %p, want the last", root.Focused())
+	}
+
+	root.Focus(nil)
+	root.FocusNext(true)
+	if root.Focused() != c {
+		t.Fatalf("backwards without focus focused %p, want the last widget", root.Focused())
+	}
+}
+
+func TestListRowAtScrolled(t *testing.T) {
+	list := NewList([]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"})
+	root := newTestRoot(list)
+	// rows are a line and a padding high: 14 units, 3 visible
+	list.Layout(root, Rect{0, 0, 100, 42})
+
+	if got := list.RowAt(root, 5, 15); got != 1 {
+		t.Errorf("RowAt(5, 15) = %d before scrolling, want 1", got)
+	}
+
+	// scrolls so row 5 is the last visible
+	list.Select(root, 5)
+	cases := []struct {
+		x, y float32
+		want int
+	}{
+		{5, 0, 3},
+		{5, 41, 5},
+		{5, 42, -1},
+		{101, 10, -1},
+	}
+	for _, c := range cases {
+		if got := list.RowAt(root, c.x, c.y); got != c.want {
+			t.Errorf("RowAt(%g, %g) = %d after selecting row 5, want %d", c.x, c.y, got, c.want)
+		}
+	}
+
+	short := NewList([]string{"only"})
+	short.Layout(root, Rect{0, 0, 100, 42})
+	if got := short.RowAt(root, 5, 20); got != -1 {
+		t.Errorf("RowAt below the last row = %d, want -1", got)
+	}
+}
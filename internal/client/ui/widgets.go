@@ -0,0 +1,317 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Height of buttons, sliders, checkboxes and text fields, in UI units.
+func controlHeight(root *Root) float32 {
+	return root.LineHeight() + 2*root.Theme.Padding
+}
+
+// Background colour of an interactive widget for its current state.
+func controlColor(root *Root, widget Widget, disabled bool) mgl32.Vec4 {
+	switch {
+	case disabled:
+		return root.Theme.WidgetPress
+	case root.IsPressed(widget):
+		return root.Theme.WidgetPress
+	case root.IsHovered(widget):
+		return root.Theme.WidgetHover
+	}
+	return root.Theme.Widget
+}
+
+// Draws text centered in rect.
+func centerText(root *Root, canvas *Canvas, rect Rect, text string, color mgl32.Vec4) {
+	width, height := root.MeasureText(text)
+	canvas.Text(text, rect.X+(rect.W-width)/2, rect.Y+(rect.H-height)/2, color)
+}
+
+// Whether a key event activates the focused widget.
+func isActivation(event Event) bool {
+	return event.Kind == EVENT_KEY && (event.Key == KEY_ENTER || event.Key == KEY_SPACE)
+}
+
+// Label is a line of static text.
+type Label struct {
+	base
+	Text string
+	// theme text colour if transparent
+	Color mgl32.Vec4
+	// horizontal placement of the text in the label
+	Align Align
+}
+
+func NewLabel(text string) *Label {
+	return &Label{Text: text}
+}
+
+func (self *Label) Measure(root *Root) (float32, float32) {
+	return root.MeasureText(self.Text)
+}
+
+func (self *Label) Paint(root *Root, canvas *Canvas) {
+	color := self.Color
+	if color[3] == 0 {
+		color = root.Theme.Text
+	}
+
+	width, height := root.MeasureText(self.Text)
+	x := self.bounds.X
+	switch self.Align {
+	case ALIGN_CENTER, ALIGN_STRETCH:
+		x += (self.bounds.W - width) / 2
+	case ALIGN_END:
+		x += self.bounds.W - width
+	}
+	canvas.Text(self.Text, x, self.bounds.Y+(self.bounds.H-height)/2, color)
+}
+
+// Button runs OnClick when clicked, or activated with Enter or Space while
+// focused.
+type Button struct {
+	base
+	Text     string
+	Disabled bool
+	OnClick  func()
+}
+
+func NewButton(text string, onClick func()) *Button {
+	return &Button{Text: text, OnClick: onClick}
+}
+
+func (self *Button) CanFocus() bool {
+	return !self.Disabled
+}
+
+func (self *Button) Measure(root *Root) (float32, float32) {
+	width, _ := root.MeasureText(self.Text)
+	return width + 4*root.Theme.Padding, controlHeight(root)
+}
+
+func (self *Button) click() {
+	if self.OnClick != nil {
+		self.OnClick()
+	}
+}
+
+func (self *Button) HandleEvent(root *Root, event Event) bool {
+	if self.Disabled {
+		return false
+	}
+	switch {
+	case event.Kind == EVENT_MOUSE_DOWN:
+		return true
+	case event.Kind == EVENT_MOUSE_UP:
+		// releasing the button outside cancels the click
+		if self.bounds.Contains(event.X, event.Y) {
+			self.click()
+		}
+		return true
+	case isActivation(event):
+		self.click()
+		return true
+	}
+	return false
+}
+
+func (self *Button) Paint(root *Root, canvas *Canvas) {
+	canvas.FillRect(self.bounds, controlColor(root, self, self.Disabled))
+	if root.IsFocused(self) {
+		canvas.Outline(self.bounds, root.Theme.Border, root.Theme.Focus)
+	}
+	color := root.Theme.Text
+	if self.Disabled {
+		color = root.Theme.TextDisabled
+	}
+	centerText(root, canvas, self.bounds, self.Text, color)
+}
+
+// Checkbox toggles Checked when clicked, or activated while focused.
+type Checkbox struct {
+	base
+	Text     string
+	Checked  bool
+	OnChange func(checked bool)
+}
+
+func NewCheckbox(text string, checked bool, onChange func(checked bool)) *Checkbox {
+	return &Checkbox{Text: text, Checked: checked, OnChange: onChange}
+}
+
+func (self *Checkbox) CanFocus() bool {
+	return true
+}
+
+func (self *Checkbox) Measure(root *Root) (float32, float32) {
+	width, _ := root.MeasureText(self.Text)
+	height := controlHeight(root)
+	return height + root.Theme.Spacing + width, height
+}
+
+func (self *Checkbox) toggle() {
+	self.Checked = !self.Checked
+	if self.OnChange != nil {
+		self.OnChange(self.Checked)
+	}
+}
+
+func (self *Checkbox) HandleEvent(root *Root, event Event) bool {
+	switch {
+	case event.Kind == EVENT_MOUSE_DOWN:
+		return true
+	case event.Kind == EVENT_MOUSE_UP:
+		if self.bounds.Contains(event.X, event.Y) {
+			self.toggle()
+		}
+		return true
+	case isActivation(event):
+		self.toggle()
+		return true
+	}
+	return false
+}
+
+func (self *Checkbox) Paint(root *Root, canvas *Canvas) {
+	theme := root.Theme
+	box := Rect{self.bounds.X, self.bounds.Y, self.bounds.H, self.bounds.H}
+	canvas.FillRect(box, controlColor(root, self, false))
+	if self.Checked {
+		canvas.FillRect(box.Inset(theme.Padding), theme.Accent)
+	}
+	if root.IsFocused(self) {
+		canvas.Outline(box, theme.Border, theme.Focus)
+	}
+
+	_, height := root.MeasureText(self.Text)
+	x := box.X + box.W + theme.Spacing
+	canvas.Text(self.Text, x, self.bounds.Y+(self.bounds.H-height)/2, theme.Text)
+}
+
+// Default width of sliders and text fields, in UI units.
+const FIELD_WIDTH = 160
+
+// Slider picks a value between Min and Max by dragging, or with the arrow
+// keys while focused. The label and value are drawn over the track.
+type Slider struct {
+	base
+	Text  string
+	Min   float32
+	Max   float32
+	Value float32
+	// the value snaps to multiples of Step from Min; continuous if 0
+	Step float32
+	// formats the value shown after the label; "%.2f" if nil
+	Format   func(value float32) string
+	OnChange func(value float32)
+}
+
+func NewSlider(text string, min, max, value float32, onChange func(value float32)) *Slider {
+	return &Slider{Text: text, Min: min, Max: max, Value: value, OnChange: onChange}
+}
+
+func (self *Slider) CanFocus() bool {
+	return true
+}
+
+func (self *Slider) Measure(root *Root) (float32, float32) {
+	width, _ := root.MeasureText(self.label())
+	return maxf(FIELD_WIDTH, width+4*root.Theme.Padding), controlHeight(root)
+}
+
+func (self *Slider) label() string {
+	value := fmt.Sprintf("%.2f", self.Value)
+	if self.Format != nil {
+		value = self.Format(self.Value)
+	}
+	if self.Text == "" {
+		return value
+	}
+	return self.Text + ": " + value
+}
+
+// SetValue snaps and clamps the value, and calls OnChange if it changed.
+func (self *Slider) SetValue(value float32) {
+	if self.Step > 0 {
+		value = self.Min + float32(math.Round(float64((value-self.Min)/self.Step)))*self.Step
+	}
+	value = clampf(value, self.Min, self.Max)
+	if value == self.Value {
+		return
+	}
+	self.Value = value
+	if self.OnChange != nil {
+		self.OnChange(value)
+	}
+}
+
+// Fraction of the track covered, from 0 to 1.
+func (self *Slider) fraction() float32 {
+	if self.Max <= self.Min {
+		return 0
+	}
+	return clampf((self.Value-self.Min)/(self.Max-self.Min), 0, 1)
+}
+
+// Value at a horizontal position over the track.
+func (self *Slider) valueAt(x float32) float32 {
+	if self.bounds.W <= 0 {
+		return self.Value
+	}
+	return self.Min + clampf((x-self.bounds.X)/self.bounds.W, 0, 1)*(self.Max-self.Min)
+}
+
+func (self *Slider) HandleEvent(root *Root, event Event) bool {
+	step := self.Step
+	if step <= 0 {
+		step = (self.Max - self.Min) / 20
+	}
+
+	switch event.Kind {
+	case EVENT_MOUSE_DOWN:
+		self.SetValue(self.valueAt(event.X))
+		return true
+	case EVENT_MOUSE_MOVE:
+		if root.IsPressed(self) {
+			self.SetValue(self.valueAt(event.X))
+			return true
+		}
+	case EVENT_MOUSE_UP:
+		return true
+	case EVENT_KEY:
+		switch event.Key {
+		case KEY_LEFT, KEY_DOWN:
+			self.SetValue(self.Value - step)
+			return true
+		case KEY_RIGHT, KEY_UP:
+			self.SetValue(self.Value + step)
+			return true
+		case KEY_HOME:
+			self.SetValue(self.Min)
+			return true
+		case KEY_END:
+			self.SetValue(self.Max)
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Slider) Paint(root *Root, canvas *Canvas) {
+	theme := root.Theme
+	canvas.FillRect(self.bounds, controlColor(root, self, false))
+
+	// the handle is as wide as the padding on both sides of it
+	handleWidth := 2 * theme.Padding
+	x := self.bounds.X + self.fraction()*(self.bounds.W-handleWidth)
+	canvas.FillRect(Rect{x, self.bounds.Y, handleWidth, self.bounds.H}, theme.Accent)
+
+	if root.IsFocused(self) {
+		canvas.Outline(self.bounds, theme.Border, theme.Focus)
+	}
+	centerText(root, canvas, self.bounds, self.label(), theme.Text)
+}
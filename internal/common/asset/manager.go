@@ -0,0 +1,130 @@
+package asset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// A named source of assets in a Manager's stack.
+type Layer struct {
+	Name string
+	FS   fs.FS
+}
+
+// Manager resolves asset names through an ordered stack of file systems: the
+// first layer that contains a file wins. It implements fs.FS itself, so it
+// can be passed anywhere a file system is expected. Directory listings merge
+// the entries of every layer.
+type Manager struct {
+	layers []Layer // highest priority first
+}
+
+func NewManager(layers ...Layer) *Manager {
+	return &Manager{append([]Layer(nil), layers...)}
+}
+
+// Push adds a layer with a higher priority than every existing layer.
+func (self *Manager) Push(layer Layer) {
+	self.layers = append([]Layer{layer}, self.layers...)
+}
+
+// Close releases the layers that hold open files, such as zipped resource
+// packs. The manager must not be used afterwards.
+func (self *Manager) Close() error {
+	var first error
+	for _, layer := range self.layers {
+		if closer, ok := layer.FS.(io.Closer); ok {
+			err := closer.Close()
+			if err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	self.layers = nil
+	return first
+}
+
+// Layers returns the stack, highest priority first.
+func (self *Manager) Layers() []Layer {
+	return append([]Layer(nil), self.layers...)
+}
+
+// Resolve returns the layer that provides name.
+func (self *Manager) Resolve(name string) (Layer, error) {
+	if !fs.ValidPath(name) {
+		return Layer{}, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for _, layer := range self.layers {
+		_, err := fs.Stat(layer.FS, name)
+		if err == nil {
+			return layer, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return Layer{}, fmt.Errorf("asset layer `%s`: %w", layer.Name, err)
+		}
+	}
+
+	return Layer{}, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (self *Manager) Open(name string) (fs.File, error) {
+	layer, err := self.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return layer.FS.Open(name)
+}
+
+func (self *Manager) ReadFile(name string) ([]byte, error) {
+	layer, err := self.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.ReadFile(layer.FS, name)
+}
+
+// ReadDir lists the union of the entries of name in every layer. When several
+// layers have an entry with the same name, the one with the highest priority
+// is returned.
+func (self *Manager) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	found := false
+	entries := make(map[string]fs.DirEntry)
+	for _, layer := range self.layers {
+		layerEntries, err := fs.ReadDir(layer.FS, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("asset layer `%s`: %w", layer.Name, err)
+		}
+
+		found = true
+		for _, entry := range layerEntries {
+			if _, ok := entries[entry.Name()]; !ok {
+				entries[entry.Name()] = entry
+			}
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+
+	return list, nil
+}
@@ -0,0 +1,126 @@
+package asset
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Name of the manifest file at the root of every resource pack.
+const PACK_MANIFEST = "pack.json"
+
+type PackManifest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// packs with a higher priority override those with a lower one
+	Priority int `json:"priority"`
+}
+
+// A resource pack is a directory or a zip file holding a manifest and any
+// assets it overrides, laid out like the embedded assets (blocks.json,
+// textures/, shaders/).
+type Pack struct {
+	Path     string
+	Manifest PackManifest
+	FS       fs.FS
+}
+
+type zipPack struct {
+	*zip.ReadCloser
+}
+
+func (self zipPack) Open(name string) (fs.File, error) {
+	return self.ReadCloser.Open(name)
+}
+
+// OpenPack opens the directory or zip file at path and reads its manifest.
+func OpenPack(path string) (*Pack, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pack := Pack{Path: path}
+	if info.IsDir() {
+		pack.FS = os.DirFS(path)
+	} else if strings.EqualFold(filepath.Ext(path), ".zip") {
+		reader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("resource pack %s: %w", path, err)
+		}
+		pack.FS = zipPack{reader}
+	} else {
+		return nil, fmt.Errorf("resource pack %s: not a directory or zip file", path)
+	}
+
+	data, err := fs.ReadFile(pack.FS, PACK_MANIFEST)
+	if err == nil {
+		err = json.Unmarshal(data, &pack.Manifest)
+	}
+	if err != nil {
+		pack.Close()
+		return nil, fmt.Errorf("resource pack %s: bad manifest: %w", path, err)
+	}
+
+	if pack.Manifest.Name == "" {
+		pack.Manifest.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return &pack, nil
+}
+
+func (self *Pack) Close() error {
+	if closer, ok := self.FS.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (self *Pack) Layer() Layer {
+	return Layer{Name: self.Manifest.Name, FS: self.FS}
+}
+
+// OpenPacks opens every pack in dir, sorted from the highest to the lowest
+// priority (then by name). Entries that fail to open are reported through the
+// returned errors but do not prevent the other packs from loading. A missing
+// directory simply has no packs.
+func OpenPacks(dir string) ([]*Pack, []error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	packs := make([]*Pack, 0, len(entries))
+	errs := make([]error, 0)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		pack, err := OpenPack(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+
+	sort.SliceStable(packs, func(i, j int) bool {
+		if packs[i].Manifest.Priority != packs[j].Manifest.Priority {
+			return packs[i].Manifest.Priority > packs[j].Manifest.Priority
+		}
+		return packs[i].Manifest.Name < packs[j].Manifest.Name
+	})
+
+	return packs, errs
+}
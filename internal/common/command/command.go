@@ -0,0 +1,61 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Handler runs a command with its arguments (the words following the command
+// name) and returns a message for the user.
+type Handler func(args []string) (string, error)
+
+type command struct {
+	usage   string
+	handler Handler
+}
+
+// Registry maps command names to their handlers. Commands are entered as a
+// single line, e.g. "/time set noon"; the leading slash is optional.
+type Registry struct {
+	commands map[string]command
+}
+
+func NewRegistry() *Registry {
+	registry := Registry{make(map[string]command)}
+	registry.Register("help", "help", registry.help)
+	return &registry
+}
+
+// Register adds a command. usage is shown by "help" and when the command
+// fails.
+func (self *Registry) Register(name, usage string, handler Handler) {
+	self.commands[name] = command{usage, handler}
+}
+
+func (self *Registry) Execute(line string) (string, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	cmd, ok := self.commands[fields[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown command `%s`, try `help`", fields[0])
+	}
+
+	message, err := cmd.handler(fields[1:])
+	if err != nil {
+		return "", fmt.Errorf("%w\nusage: %s", err, cmd.usage)
+	}
+	return message, nil
+}
+
+func (self *Registry) help(args []string) (string, error) {
+	usages := make([]string, 0, len(self.commands))
+	for _, cmd := range self.commands {
+		usages = append(usages, cmd.usage)
+	}
+	sort.Strings(usages)
+	return strings.Join(usages, "\n"), nil
+}
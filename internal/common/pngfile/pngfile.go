@@ -0,0 +1,44 @@
+// Package pngfile reads and writes PNG files on disk for the commands. Unlike
+// scene.LoadImage, it keeps the rows top first, as they are in the file.
+package pngfile
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+)
+
+// Load decodes a PNG file into an RGBA image with its origin at 0, 0.
+func Load(path string) (*image.RGBA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	rgba := image.NewRGBA(image.Rectangle{image.Point{}, img.Bounds().Size()})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+// Save encodes an image into a PNG file, replacing it if it exists.
+func Save(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = png.Encode(file, img)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
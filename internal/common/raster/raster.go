@@ -0,0 +1,316 @@
+// Package raster draws textured triangle meshes into images on the CPU,
+// without any GL context. It follows the conventions of the GL renderer
+// (counter-clockwise front faces, OpenGL clip space, texture rows bottom
+// first) closely enough to preview chunk meshes in tests and tools.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/space"
+)
+
+// VertexLayout gives the offsets of the attributes in an interleaved float
+// vertex buffer.
+type VertexLayout struct {
+	// floats per vertex
+	Stride int
+	// x, y, z
+	Position int
+	// s, t and texture layer
+	TexCoord int
+	// sky light, block light and ambient occlusion, from 0 to 1; -1 if the
+	// vertices are unlit
+	Light int
+	// light emitted by the surface, from 0 to 1; -1 if none
+	Emission int
+}
+
+// Layout of the chunk meshes built by scene.MeshChunk.
+var ChunkVertexLayout = VertexLayout{
+	Stride:   scene.CHUNK_VERTEX_COMPONENTS,
+	Position: scene.VERTEX_POSITION,
+	TexCoord: scene.VERTEX_TEX_COORD,
+	Light:    scene.VERTEX_LIGHT,
+	Emission: scene.VERTEX_EMISSION,
+}
+
+// Target is a colour image with a depth buffer.
+type Target struct {
+	Color *image.RGBA
+	// depth of each pixel, from 0 (near) to 1 (far)
+	Depth []float32
+}
+
+func NewTarget(width, height int) *Target {
+	return &Target{
+		Color: image.NewRGBA(image.Rect(0, 0, width, height)),
+		Depth: make([]float32, width*height),
+	}
+}
+
+// Clear fills the image with c and resets the depth to the far plane.
+func (self *Target) Clear(c color.RGBA) {
+	for i := 0; i < len(self.Color.Pix); i += 4 {
+		self.Color.Pix[i+0] = c.R
+		self.Color.Pix[i+1] = c.G
+		self.Color.Pix[i+2] = c.B
+		self.Color.Pix[i+3] = c.A
+	}
+	for i := range self.Depth {
+		self.Depth[i] = 1
+	}
+}
+
+// Rasterizer draws meshes into a target with depth testing, back face
+// culling and perspective correct, nearest texture sampling.
+type Rasterizer struct {
+	Target     *Target
+	Projection mgl32.Mat4
+	View       mgl32.Mat4
+	Layout     VertexLayout
+	// texture of each layer, rows bottom first like the images uploaded to
+	// the GL texture array
+	Textures []*image.RGBA
+	// multiplier for the sky light, as in the chunk shader
+	SkyLight float32
+	// skip triangles facing away from the camera
+	CullBackFaces bool
+}
+
+func NewRasterizer(target *Target, layout VertexLayout, textures []*image.RGBA) *Rasterizer {
+	return &Rasterizer{
+		Target:        target,
+		Projection:    mgl32.Ident4(),
+		View:          mgl32.Ident4(),
+		Layout:        layout,
+		Textures:      textures,
+		SkyLight:      1,
+		CullBackFaces: true,
+	}
+}
+
+// SetCamera sets a perspective projection matching the size of the target,
+// viewed from camera.
+func (self *Rasterizer) SetCamera(camera space.Transform, fovy, near, far float32) {
+	size := self.Target.Color.Rect.Size()
+	self.Projection = mgl32.Perspective(fovy, float32(size.X)/float32(size.Y), near, far)
+	self.View = camera.ViewMatrix()
+}
+
+// Interpolated vertex attributes: s, t, sky light, block light, ambient
+// occlusion and emission.
+const attributeCount = 6
+
+type clipVertex struct {
+	position   mgl32.Vec4
+	attributes [attributeCount]float32
+}
+
+// Linear interpolation between two vertices, for clipping.
+func lerpVertex(a, b clipVertex, t float32) clipVertex {
+	v := clipVertex{position: a.position.Add(b.position.Sub(a.position).Mul(t))}
+	for i := range v.attributes {
+		v.attributes[i] = a.attributes[i] + (b.attributes[i]-a.attributes[i])*t
+	}
+	return v
+}
+
+func (self *Rasterizer) vertex(vertices []float32, index uint32, mvp mgl32.Mat4) clipVertex {
+	layout := self.Layout
+	base := int(index) * layout.Stride
+	p := vertices[base+layout.Position:]
+	t := vertices[base+layout.TexCoord:]
+
+	v := clipVertex{position: mvp.Mul4x1(mgl32.Vec4{p[0], p[1], p[2], 1})}
+	v.attributes = [attributeCount]float32{t[0], t[1], 1, 0, 1, 0}
+	if layout.Light >= 0 {
+		l := vertices[base+layout.Light:]
+		v.attributes[2], v.attributes[3], v.attributes[4] = l[0], l[1], l[2]
+	}
+	if layout.Emission >= 0 {
+		v.attributes[5] = vertices[base+layout.Emission]
+	}
+	return v
+}
+
+// DrawMesh draws the indexed triangles of a mesh and returns how many of
+// them left at least one pixel.
+func (self *Rasterizer) DrawMesh(vertices []float32, indices []uint32) int {
+	mvp := self.Projection.Mul4(self.View)
+	drawn := 0
+	for i := 0; i+2 < len(indices); i += 3 {
+		triangle := [3]clipVertex{
+			self.vertex(vertices, indices[i], mvp),
+			self.vertex(vertices, indices[i+1], mvp),
+			self.vertex(vertices, indices[i+2], mvp),
+		}
+		layer := int(vertices[int(indices[i])*self.Layout.Stride+self.Layout.TexCoord+2])
+
+		polygon := clipNear(triangle[:])
+		hit := false
+		for j := 1; j+1 < len(polygon); j++ {
+			if self.drawTriangle(polygon[0], polygon[j], polygon[j+1], layer) {
+				hit = true
+			}
+		}
+		if hit {
+			drawn++
+		}
+	}
+	return drawn
+}
+
+// Clips a polygon against the near plane (z >= -w), so every vertex left
+// is in front of the camera.
+func clipNear(polygon []clipVertex) []clipVertex {
+	clipped := make([]clipVertex, 0, len(polygon)+1)
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		da := a.position[2] + a.position[3]
+		db := b.position[2] + b.position[3]
+
+		if da >= 0 {
+			clipped = append(clipped, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			clipped = append(clipped, lerpVertex(a, b, da/(da-db)))
+		}
+	}
+	return clipped
+}
+
+type screenVertex struct {
+	x, y, z    float32
+	invW       float32
+	attributes [attributeCount]float32
+}
+
+func (self *Rasterizer) toScreen(v clipVertex) screenVertex {
+	size := self.Target.Color.Rect.Size()
+	invW := 1 / v.position[3]
+	s := screenVertex{
+		x:    (v.position[0]*invW*0.5 + 0.5) * float32(size.X),
+		y:    (0.5 - v.position[1]*invW*0.5) * float32(size.Y),
+		z:    v.position[2]*invW*0.5 + 0.5,
+		invW: invW,
+	}
+	// attributes are interpolated divided by w, for perspective correction
+	for i, a := range v.attributes {
+		s.attributes[i] = a * invW
+	}
+	return s
+}
+
+func edge(a, b screenVertex, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// Draws a triangle in front of the near plane, and returns whether any pixel
+// passed the depth test.
+func (self *Rasterizer) drawTriangle(c0, c1, c2 clipVertex, layer int) bool {
+	a, b, c := self.toScreen(c0), self.toScreen(c1), self.toScreen(c2)
+
+	// y points down on screen, which turns counter-clockwise front faces
+	// clockwise, with a negative area
+	area := edge(a, b, c.x, c.y)
+	if area == 0 || (self.CullBackFaces && area > 0) {
+		return false
+	}
+
+	size := self.Target.Color.Rect.Size()
+	minX := int(math.Max(0, math.Floor(float64(min3(a.x, b.x, c.x)))))
+	maxX := int(math.Min(float64(size.X-1), math.Ceil(float64(max3(a.x, b.x, c.x)))))
+	minY := int(math.Max(0, math.Floor(float64(min3(a.y, b.y, c.y)))))
+	maxY := int(math.Min(float64(size.Y-1), math.Ceil(float64(max3(a.y, b.y, c.y)))))
+
+	var texture *image.RGBA
+	if layer >= 0 && layer < len(self.Textures) {
+		texture = self.Textures[layer]
+	}
+
+	hit := false
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px, py := float32(x)+0.5, float32(y)+0.5
+			w0 := edge(b, c, px, py) / area
+			w1 := edge(c, a, px, py) / area
+			w2 := edge(a, b, px, py) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+
+			z := w0*a.z + w1*b.z + w2*c.z
+			i := y*size.X + x
+			if z < 0 || z > 1 || z >= self.Target.Depth[i] {
+				continue
+			}
+
+			invW := w0*a.invW + w1*b.invW + w2*c.invW
+			var attributes [attributeCount]float32
+			for j := range attributes {
+				attributes[j] = (w0*a.attributes[j] + w1*b.attributes[j] + w2*c.attributes[j]) / invW
+			}
+
+			color, ok := self.shade(texture, attributes)
+			if !ok {
+				continue
+			}
+
+			self.Target.Depth[i] = z
+			self.Target.Color.SetRGBA(x, y, color)
+			hit = true
+		}
+	}
+	return hit
+}
+
+// Maps a light level to a brightness, as chunk.frag does.
+func brightness(level float32) float32 {
+	return 0.04 + (1-0.04)*float32(math.Pow(0.8, float64((1-level)*15)))
+}
+
+// Colour of a fragment, false if it is transparent and must be discarded.
+func (self *Rasterizer) shade(texture *image.RGBA, attributes [attributeCount]float32) (color.RGBA, bool) {
+	texel := color.RGBA{255, 0, 255, 255}
+	if texture != nil {
+		size := texture.Rect.Size()
+		s := attributes[0] - float32(math.Floor(float64(attributes[0])))
+		t := attributes[1] - float32(math.Floor(float64(attributes[1])))
+		tx := clamp(int(s*float32(size.X)), 0, size.X-1)
+		ty := clamp(int(t*float32(size.Y)), 0, size.Y-1)
+		texel = texture.RGBAAt(texture.Rect.Min.X+tx, texture.Rect.Min.Y+ty)
+	}
+	if texel.A < 128 {
+		return color.RGBA{}, false
+	}
+
+	sky, block, ao, emission := attributes[2]*self.SkyLight, attributes[3], attributes[4], attributes[5]
+	light := brightness(float32(math.Max(float64(sky), float64(block))))*(0.45+0.55*ao) + emission
+	scale := func(c uint8) uint8 {
+		return uint8(math.Min(255, float64(c)*float64(light)))
+	}
+	return color.RGBA{scale(texel.R), scale(texel.G), scale(texel.B), 255}, true
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
@@ -0,0 +1,149 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/scene"
+	"github.com/hexagon-0/voxel-game/internal/common/space"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Position, then s, t and texture layer; unlit.
+var TEST_LAYOUT = VertexLayout{Stride: 6, Position: 0, TexCoord: 3, Light: -1, Emission: -1}
+
+var (
+	RED   = color.RGBA{255, 0, 0, 255}
+	BLUE  = color.RGBA{0, 0, 255, 255}
+	BLACK = color.RGBA{0, 0, 0, 255}
+)
+
+func solidTexture(c color.RGBA) *image.RGBA {
+	texture := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	texture.SetRGBA(0, 0, c)
+	return texture
+}
+
+// A square from (x0, y0) to (x1, y1) in clip space at depth z, facing the
+// camera unless flipped.
+func quad(x0, y0, x1, y1, z float32, layer float32, flipped bool) ([]float32, []uint32) {
+	vertices := []float32{
+		x0, y0, z, 0, 0, layer,
+		x1, y0, z, 1, 0, layer,
+		x1, y1, z, 1, 1, layer,
+		x0, y1, z, 0, 1, layer,
+	}
+	indices := []uint32{0, 1, 2, 0, 2, 3}
+	if flipped {
+		indices = []uint32{0, 2, 1, 0, 3, 2}
+	}
+	return vertices, indices
+}
+
+func newTestRasterizer() *Rasterizer {
+	target := NewTarget(8, 8)
+	target.Clear(BLACK)
+	// identity camera: vertices are already in clip space
+	return NewRasterizer(target, TEST_LAYOUT, []*image.RGBA{solidTexture(RED), solidTexture(BLUE)})
+}
+
+func TestDepthOrder(t *testing.T) {
+	for _, nearFirst := range []bool{true, false} {
+		r := newTestRasterizer()
+		nearVertices, nearIndices := quad(-1, -1, 0.5, 0.5, -0.5, 0, false)
+		farVertices, farIndices := quad(-0.5, -0.5, 1, 1, 0.5, 1, false)
+		if nearFirst {
+			r.DrawMesh(nearVertices, nearIndices)
+			r.DrawMesh(farVertices, farIndices)
+		} else {
+			r.DrawMesh(farVertices, farIndices)
+			r.DrawMesh(nearVertices, nearIndices)
+		}
+
+		// the image has y down, clip space y up
+		cases := []struct {
+			x, y int
+			want color.RGBA
+		}{
+			{1, 6, RED},  // near quad only
+			{4, 4, RED},  // overlap, near quad in front
+			{7, 0, BLUE}, // far quad only
+			{0, 0, BLACK},
+		}
+		for _, c := range cases {
+			if got := r.Target.Color.RGBAAt(c.x, c.y); got != c.want {
+				t.Errorf("near first %t: pixel (%d, %d) is %v, want %v", nearFirst, c.x, c.y, got, c.want)
+			}
+		}
+	}
+}
+
+func TestBackFaceCulling(t *testing.T) {
+	r := newTestRasterizer()
+	vertices, indices := quad(-1, -1, 1, 1, 0, 0, true)
+	if drawn := r.DrawMesh(vertices, indices); drawn != 0 {
+		t.Fatalf("%d back facing triangles drawn, want 0", drawn)
+	}
+	if got := r.Target.Color.RGBAAt(4, 4); got != BLACK {
+		t.Fatalf("pixel (4, 4) is %v after culling, want %v", got, BLACK)
+	}
+
+	r.CullBackFaces = false
+	if drawn := r.DrawMesh(vertices, indices); drawn != 2 {
+		t.Fatalf("%d triangles drawn without culling, want 2", drawn)
+	}
+	if got := r.Target.Color.RGBAAt(4, 4); got != RED {
+		t.Fatalf("pixel (4, 4) is %v without culling, want %v", got, RED)
+	}
+}
+
+// A chunk holding a single block at its origin, textured blue on top and red
+// on the other faces.
+func singleBlockMesh() ([]float32, []uint32) {
+	chunk := world.GenerateChunk(0, 0, 0, 2, 2, 2, func(x, y, z int) world.BlockId {
+		if x == 0 && y == 0 && z == 0 {
+			return 1
+		}
+		return 0
+	})
+	layers := scene.BlockLayers{1: {0, 0, 1, 0, 0, 0}}
+	return scene.MeshChunk(chunk, [3]float32{}, layers, nil)
+}
+
+func TestDrawChunkMesh(t *testing.T) {
+	vertices, indices := singleBlockMesh()
+	if faces := len(indices) / 6; faces != 6 {
+		t.Fatalf("single block meshed into %d faces, want 6", faces)
+	}
+
+	cases := []struct {
+		name  string
+		eye   mgl32.Vec3
+		drawn int
+		want  color.RGBA
+	}{
+		// only the face towards the camera is visible, the sides are seen edge on
+		{"front", mgl32.Vec3{0.5, 0.5, 4}, 2, RED},
+		{"above", mgl32.Vec3{0.5, 4, 0.6}, 2, BLUE},
+	}
+	for _, c := range cases {
+		target := NewTarget(16, 16)
+		target.Clear(BLACK)
+		r := NewRasterizer(target, ChunkVertexLayout, []*image.RGBA{solidTexture(RED), solidTexture(BLUE)})
+		r.SetCamera(space.LookAt(c.eye, mgl32.Vec3{0.5, 0.5, 0.5}, scene.WorldUp), math.Pi/4, 0.1, 100)
+
+		if drawn := r.DrawMesh(vertices, indices); drawn != c.drawn {
+			t.Errorf("%s: %d triangles drawn, want %d", c.name, drawn, c.drawn)
+		}
+		// fully lit without a light sampler, so the texture colour is kept
+		if got := target.Color.RGBAAt(8, 8); got != c.want {
+			t.Errorf("%s: block is %v in the middle of the image, want %v", c.name, got, c.want)
+		}
+		if got := target.Color.RGBAAt(0, 0); got != BLACK {
+			t.Errorf("%s: corner of the image is %v, want the background", c.name, got)
+		}
+	}
+}
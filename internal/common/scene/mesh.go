@@ -0,0 +1,269 @@
+// Package scene computes what the world looks like without any graphics
+// API: chunk meshes, the texture layers of block faces and the sky for a
+// time of day. The render package uploads and draws them with OpenGL, the
+// raster package draws them on the CPU.
+package scene
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Layout of the chunk vertices, as offsets in floats: xyz position, st tex
+// coords and texture array layer, sky light, block light and ambient
+// occlusion, then emission.
+const (
+	CHUNK_VERTEX_COMPONENTS = 10
+	VERTEX_POSITION         = 0
+	VERTEX_TEX_COORD        = 3
+	VERTEX_LIGHT            = 6
+	VERTEX_EMISSION         = 9
+)
+
+// Up in world space.
+var WorldUp = mgl32.Vec3{0, 1, 0}
+
+// Texture axes of each face, indexed by the world.FACE_* constants: the
+// position component used for s, whether s runs against it (so textures are
+// not mirrored when seen from outside), and the component used for t.
+var faceTexAxes = [6]struct {
+	s     int
+	flipS bool
+	t     int
+}{
+	{2, true, 1},
+	{2, false, 1},
+	{0, false, 2},
+	{0, false, 2},
+	{0, false, 1},
+	{0, true, 1},
+}
+
+// Returns the sky and block light levels at chunk-local coordinates, which
+// may be one block outside of the chunk, and whether the block there is
+// solid.
+type LightSampler func(x, y, z int) (sky, block uint8, solid bool)
+
+// Samples light from the world around the chunk whose first block is at key.
+func WorldLightSampler(w *world.World, key [3]int) LightSampler {
+	return func(x, y, z int) (uint8, uint8, bool) {
+		x, y, z = key[0]+x, key[1]+y, key[2]+z
+		id, _ := w.BlockAt(x, y, z)
+		return w.LightAt(world.SKY_LIGHT, x, y, z), w.LightAt(world.BLOCK_LIGHT, x, y, z), id != 0
+	}
+}
+
+// Light of a face corner, normalized to [0, 1].
+type vertexLight struct {
+	sky   float32
+	block float32
+	ao    float32
+}
+
+// Computes the smooth light of the face corner at offset o from the face
+// origin. The corner touches four blocks of the layer in front of the face:
+// front itself, its two neighbours along the face axes towards the corner and
+// the diagonal one. Light is averaged over those that are not solid, and
+// solid ones occlude the corner.
+func sampleCornerLight(light LightSampler, front [3]int, uAxis, vAxis int, o [3]float32) vertexLight {
+	if light == nil {
+		return vertexLight{1.0, 1.0, 1.0}
+	}
+
+	side1, side2 := front, front
+	if o[uAxis] > 0 {
+		side1[uAxis]++
+	} else {
+		side1[uAxis]--
+	}
+	if o[vAxis] > 0 {
+		side2[vAxis]++
+	} else {
+		side2[vAxis]--
+	}
+	corner := side1
+	corner[vAxis] = side2[vAxis]
+
+	var sky, block, count int
+	var solid [3]bool
+	for i, pos := range [4][3]int{front, side1, side2, corner} {
+		skyLevel, blockLevel, isSolid := light(pos[0], pos[1], pos[2])
+		if i > 0 {
+			solid[i-1] = isSolid
+		}
+		// light can't reach the corner block through two solid sides
+		if isSolid || i == 3 && solid[0] && solid[1] {
+			continue
+		}
+		sky += int(skyLevel)
+		block += int(blockLevel)
+		count++
+	}
+
+	// 0 to 3 free blocks around the corner
+	occlusion := 3
+	if solid[0] && solid[1] {
+		occlusion = 0
+	} else {
+		for _, isSolid := range solid {
+			if isSolid {
+				occlusion--
+			}
+		}
+	}
+
+	if count == 0 {
+		// the face is covered by a block of a neighbouring chunk
+		return vertexLight{0.0, 0.0, float32(occlusion) / 3.0}
+	}
+
+	return vertexLight{
+		float32(sky) / float32(count*world.MAX_LIGHT),
+		float32(block) / float32(count*world.MAX_LIGHT),
+		float32(occlusion) / 3.0,
+	}
+}
+
+// MeshChunk produces the culled mesh of a chunk on the CPU. Vertex positions
+// are offset by origin, indices start at zero and each face samples the
+// texture array layer given by layers. Each vertex is lit by averaging the
+// blocks around it in front of the face, as returned by light, and darkened by
+// ambient occlusion; if light is nil, everything is fully lit. Faces of light
+// emitting blocks also carry their emission, for bloom.
+func MeshChunk(chunk *world.Chunk, origin [3]float32, layers BlockLayers, light LightSampler) ([]float32, []uint32) {
+	ox, oy, oz := origin[0], origin[1], origin[2]
+	w, h, d := int(chunk.Width), int(chunk.Height), int(chunk.Depth)
+
+	dir := [3][2][3]float32{} // perpendicular vectors for each axis (for vertex building)
+	for i := 0; i < 3; i++ {
+		dir[i][0][(i+1)%3] = 1.0
+		dir[i][1][(i+2)%3] = 1.0
+	}
+
+	vertices := make([]float32, 0)
+	indices := make([]uint32, 0)
+
+	cb := [3]bool{} // for each axis, is the current block within chunk bounds
+	nb := [3]bool{} // for each axis, is the next block on that axis within chunk bounds
+
+	cb[2] = false
+	nb[2] = true
+	for k := -1; k < d; k++ {
+		nb[2] = k < d-1
+
+		cb[1] = false
+		nb[1] = true
+		for j := -1; j < h; j++ {
+			nb[1] = j < h-1
+
+			cb[0] = false
+			nb[0] = true
+			for i := -1; i < w; i++ {
+				nb[0] = i < w-1
+
+				var c world.BlockId // current block
+				if cb[0] && cb[1] && cb[2] {
+					c = chunk.BlockAt(uint(i), uint(j), uint(k))
+				}
+
+				n := [3]world.BlockId{} // neighbours for each axis
+				if nb[0] && cb[1] && cb[2] {
+					n[0] = chunk.BlockAt(uint(i)+1, uint(j), uint(k))
+				}
+				if cb[0] && nb[1] && cb[2] {
+					n[1] = chunk.BlockAt(uint(i), uint(j)+1, uint(k))
+				}
+				if cb[0] && cb[1] && nb[2] {
+					n[2] = chunk.BlockAt(uint(i), uint(j), uint(k)+1)
+				}
+
+				for di := 0; di < 3; di++ {
+					if (c != 0) != (n[di] != 0) {
+						var s int // winding order
+						if c == 0 {
+							s = 1
+						}
+
+						// the face belongs to the solid block of the pair
+						block, face := c, di*2+s
+						if c == 0 {
+							block = n[di]
+						}
+						layer := float32(layers[block][face])
+						axes := faceTexAxes[face]
+
+						// the air block in front of the face
+						front := [3]int{i, j, k}
+						if s == 0 {
+							front[di]++
+						}
+
+						t := [3]float32{float32(i), float32(j), float32(k)}
+						u := dir[di][s]
+						v := dir[di][s^1]
+						t[di]++
+
+						corners := [4][3]float32{
+							{},
+							u,
+							{u[0] + v[0], u[1] + v[1], u[2] + v[2]},
+							v,
+						}
+
+						// emitters keep their own light level, which makes
+						// them glow
+						var emission float32
+						if light != nil {
+							solid := [3]int{i, j, k}
+							if c == 0 {
+								solid[di]++
+							}
+							_, level, _ := light(solid[0], solid[1], solid[2])
+							emission = float32(level) / world.MAX_LIGHT
+						}
+
+						var cornerLight [4]vertexLight
+						for ci, o := range corners {
+							cornerLight[ci] = sampleCornerLight(light, front, (di+1+s)%3, (di+2-s)%3, o)
+						}
+
+						ui := uint32(len(vertices) / CHUNK_VERTEX_COMPONENTS)
+						for ci, o := range corners {
+							ts := o[axes.s]
+							if axes.flipS {
+								ts = 1.0 - ts
+							}
+
+							l := cornerLight[ci]
+							vertices = append(vertices,
+								ox+t[0]+o[0], oy+t[1]+o[1], oz+t[2]+o[2], ts, o[axes.t], layer, l.sky, l.block, l.ao, emission,
+							)
+						}
+
+						// split the quad along the brighter diagonal, otherwise
+						// occlusion is interpolated unevenly across the face
+						if cornerLight[0].ao+cornerLight[2].ao < cornerLight[1].ao+cornerLight[3].ao {
+							indices = append(indices,
+								ui+1, ui+2, ui+3,
+								ui+1, ui+3, ui+0,
+							)
+						} else {
+							indices = append(indices,
+								ui+0, ui+1, ui+2,
+								ui+0, ui+2, ui+3,
+							)
+						}
+					}
+				}
+
+				cb[0] = true
+			}
+
+			cb[1] = true
+		}
+
+		cb[2] = true
+	}
+
+	return vertices, indices
+}
@@ -0,0 +1,91 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Sky colours at the zenith and the horizon for the main times of day.
+var (
+	DAY_ZENITH     = mgl32.Vec3{0.26, 0.49, 0.86}
+	DAY_HORIZON    = mgl32.Vec3{0.66, 0.80, 0.95}
+	SUNSET_ZENITH  = mgl32.Vec3{0.22, 0.25, 0.50}
+	SUNSET_HORIZON = mgl32.Vec3{0.95, 0.55, 0.30}
+	NIGHT_ZENITH   = mgl32.Vec3{0.010, 0.012, 0.035}
+	NIGHT_HORIZON  = mgl32.Vec3{0.068, 0.068, 0.098}
+)
+
+// Sky light never goes fully dark, so moonlit nights stay playable.
+const MIN_SKY_LIGHT = 0.15
+
+// Fraction of the sky light blocked in the shadow of the sun and the moon.
+const (
+	SUN_SHADOW_STRENGTH  = 0.45
+	MOON_SHADOW_STRENGTH = 0.2
+)
+
+// SkyState describes the sky for a time of day.
+type SkyState struct {
+	SunDirection  mgl32.Vec3 // towards the sun
+	MoonDirection mgl32.Vec3 // towards the moon
+	Zenith        mgl32.Vec3
+	Horizon       mgl32.Vec3
+	// multiplier applied to the sky light of the chunks
+	SkyLight float32
+	// direction towards the light casting shadows, the sun during the day
+	// and the moon at night
+	LightDirection mgl32.Vec3
+	// how dark shadows are, fading out as the light nears the horizon
+	ShadowStrength float32
+	// visibility of the stars, from 0 during the day to 1 at night
+	Stars float32
+	// turns the star field along with the sun
+	StarRotation mgl32.Mat3
+}
+
+func smoothstep(edge0, edge1, x float32) float32 {
+	t := mgl32.Clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
+func mixVec3(a, b mgl32.Vec3, t float32) mgl32.Vec3 {
+	return a.Mul(1 - t).Add(b.Mul(t))
+}
+
+// NewSkyState computes the sky for a time of day, as a fraction of the day
+// starting at midnight. The sun rises in the east (+X) and sets in the west,
+// slightly tilted towards the south.
+func NewSkyState(timeOfDay float64) SkyState {
+	angle := (timeOfDay - 0.25) * 2 * math.Pi
+	sun := mgl32.Vec3{float32(math.Cos(angle)), float32(math.Sin(angle)), 0.25}.Normalize()
+
+	// 0 at night, 1 during the day, crossing over while the sun is near the
+	// horizon
+	day := smoothstep(-0.2, 0.2, sun[1])
+	// strongest when the sun is on the horizon
+	sunset := 1 - smoothstep(0, 0.35, float32(math.Abs(float64(sun[1]))))
+
+	zenith := mixVec3(NIGHT_ZENITH, DAY_ZENITH, day)
+	horizon := mixVec3(NIGHT_HORIZON, DAY_HORIZON, day)
+	zenith = mixVec3(zenith, SUNSET_ZENITH, sunset*0.5)
+	horizon = mixVec3(horizon, SUNSET_HORIZON, sunset*0.7)
+
+	moon := sun.Mul(-1)
+	light, shadow := sun, SUN_SHADOW_STRENGTH*smoothstep(0.02, 0.2, sun[1])
+	if sun[1] < 0 {
+		light, shadow = moon, MOON_SHADOW_STRENGTH*smoothstep(0.02, 0.2, moon[1])
+	}
+
+	return SkyState{
+		SunDirection:   sun,
+		MoonDirection:  moon,
+		Zenith:         zenith,
+		Horizon:        horizon,
+		SkyLight:       MIN_SKY_LIGHT + (1-MIN_SKY_LIGHT)*day,
+		LightDirection: light,
+		ShadowStrength: shadow,
+		Stars:          1 - smoothstep(-0.3, 0.05, sun[1]),
+		StarRotation:   mgl32.Rotate3DZ(float32(angle)),
+	}
+}
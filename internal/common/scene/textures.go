@@ -0,0 +1,97 @@
+package scene
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png"
+	"io/fs"
+
+	"github.com/hexagon-0/voxel-game/internal/common/world"
+)
+
+// Texture array layer of each face of a block, indexed by the world.FACE_*
+// constants.
+type BlockLayers map[world.BlockId][6]uint32
+
+// BlockImages assigns every block face texture its own layer of a texture
+// array, and holds the image of each layer.
+type BlockImages struct {
+	Layers BlockLayers
+	// layer of each texture name
+	Names map[string]uint32
+	// image of each layer
+	Images []*image.RGBA
+}
+
+// LoadBlockImages loads every texture named by the block definitions
+// through loadImage, assigning each a layer. All images must have the same
+// size.
+func LoadBlockImages(registry *world.BlockRegistry, loadImage func(name string) (*image.RGBA, error)) (BlockImages, error) {
+	textures := BlockImages{
+		Layers: make(BlockLayers),
+		Names:  make(map[string]uint32),
+	}
+
+	images := make([]*image.RGBA, 0)
+	for _, def := range registry.Defs() {
+		var layers [6]uint32
+		for face, name := range def.Textures {
+			layer, ok := textures.Names[name]
+			if !ok {
+				img, err := loadImage(name)
+				if err != nil {
+					return BlockImages{}, fmt.Errorf("block `%s`: %w", def.Name, err)
+				}
+				if len(images) > 0 && img.Rect.Size() != images[0].Rect.Size() {
+					return BlockImages{}, fmt.Errorf(
+						"texture `%s` is %v, expected %v like the other block textures",
+						name, img.Rect.Size(), images[0].Rect.Size(),
+					)
+				}
+
+				layer = uint32(len(images))
+				images = append(images, img)
+				textures.Names[name] = layer
+			}
+			layers[face] = layer
+		}
+		textures.Layers[def.Id] = layers
+	}
+
+	textures.Images = images
+	return textures, nil
+}
+
+// Decodes an image file into RGBA, flipped vertically to match OpenGL's
+// texture coordinates.
+func LoadImage(assets fs.FS, path string) (*image.RGBA, error) {
+	imgFile, err := assets.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return nil, err
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	if rgba.Stride != rgba.Rect.Size().X*4 {
+		return nil, fmt.Errorf("Unsupported image stride")
+	}
+
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+
+	// flip vertically
+	tmp := image.NewRGBA(rgba.Bounds())
+	height := rgba.Rect.Size().Y
+	s := rgba.Stride
+	for i := 0; i < height; i++ {
+		j := height - i
+		copy(tmp.Pix[(j-1)*s:j*s], rgba.Pix[i*s:(i+1)*s])
+	}
+
+	return tmp, nil
+}
@@ -41,3 +41,13 @@ func (self Transform) ViewMatrix() mgl32.Mat4 {
 	)
 }
 
+
+// LookAt returns the transform of a camera at origin looking towards target.
+// As with OpenGL cameras, the camera looks down its -Z axis, so ViewMatrix
+// gives the matching view matrix.
+func LookAt(origin, target, up mgl32.Vec3) Transform {
+	z := origin.Sub(target).Normalize()
+	x := up.Cross(z).Normalize()
+	y := z.Cross(x)
+	return Transform{origin, Basis(mgl32.Mat3FromCols(x, y, z))}
+}
@@ -0,0 +1,32 @@
+package space
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ParseVec3 parses a vector written as x,y,z, like the ones given to the
+// commands on the command line.
+func ParseVec3(s string) (mgl32.Vec3, error) {
+	var v mgl32.Vec3
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("expected x,y,z, got `%s`", s)
+	}
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return v, fmt.Errorf("expected x,y,z, got `%s`", s)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// FormatVec3 writes a vector in the form read by ParseVec3.
+func FormatVec3(v mgl32.Vec3) string {
+	return fmt.Sprintf("%g,%g,%g", v[0], v[1], v[2])
+}
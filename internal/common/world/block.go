@@ -0,0 +1,163 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Face indices, in the same order as the face normals used by the shaders.
+const (
+	FACE_POS_X = iota
+	FACE_NEG_X
+	FACE_POS_Y
+	FACE_NEG_Y
+	FACE_POS_Z
+	FACE_NEG_Z
+)
+
+var faceNames = [6]string{"+x", "-x", "+y", "-y", "+z", "-z"}
+
+// FaceName returns the short name of a face ("+x", "-z"...), as used by the
+// block definitions.
+func FaceName(face int) string {
+	if face < 0 || face >= len(faceNames) {
+		return "none"
+	}
+	return faceNames[face]
+}
+
+// FaceNormal returns the offset from a block to its neighbour across a face,
+// or no offset for an invalid face.
+func FaceNormal(face int) [3]int {
+	if face < 0 || face >= len(neighbourOffsets) {
+		return [3]int{}
+	}
+	return neighbourOffsets[face]
+}
+
+type BlockDef struct {
+	Id   BlockId
+	Name string
+	// texture name for each face, indexed by the FACE_* constants
+	Textures [6]string
+	// block light level emitted, from 0 to MAX_LIGHT
+	Light uint8
+	// water-like block the camera can be submerged in
+	Liquid bool
+}
+
+type blockDefJson struct {
+	Id       BlockId           `json:"id"`
+	Name     string            `json:"name"`
+	Textures map[string]string `json:"textures"`
+	Light    uint8             `json:"light"`
+	Liquid   bool              `json:"liquid"`
+}
+
+// BlockRegistry holds the definitions of every block type, indexed by id.
+// Block 0 is always air and has no definition.
+type BlockRegistry struct {
+	defs   map[BlockId]*BlockDef
+	byName map[string]*BlockDef
+}
+
+// ParseBlockRegistry reads block definitions from a JSON array. Each entry's
+// "textures" object may use the keys "all", "side" (the four horizontal
+// faces), "top", "bottom" or an individual face ("+x", "-z"...), the more
+// specific keys taking precedence.
+func ParseBlockRegistry(data []byte) (*BlockRegistry, error) {
+	var entries []blockDefJson
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse block definitions: %w", err)
+	}
+
+	registry := BlockRegistry{
+		make(map[BlockId]*BlockDef),
+		make(map[string]*BlockDef),
+	}
+
+	for _, entry := range entries {
+		if entry.Id == 0 {
+			return nil, fmt.Errorf("block `%s`: id 0 is reserved for air", entry.Name)
+		}
+		if _, ok := registry.defs[entry.Id]; ok {
+			return nil, fmt.Errorf("block `%s`: duplicate id %d", entry.Name, entry.Id)
+		}
+		if _, ok := registry.byName[entry.Name]; ok {
+			return nil, fmt.Errorf("duplicate block name `%s`", entry.Name)
+		}
+
+		if entry.Light > MAX_LIGHT {
+			return nil, fmt.Errorf("block `%s`: light %d is above the maximum of %d", entry.Name, entry.Light, MAX_LIGHT)
+		}
+
+		def := BlockDef{Id: entry.Id, Name: entry.Name, Light: entry.Light, Liquid: entry.Liquid}
+		for face := range def.Textures {
+			def.Textures[face] = entry.Textures["all"]
+			if face != FACE_POS_Y && face != FACE_NEG_Y && entry.Textures["side"] != "" {
+				def.Textures[face] = entry.Textures["side"]
+			}
+			if face == FACE_POS_Y && entry.Textures["top"] != "" {
+				def.Textures[face] = entry.Textures["top"]
+			}
+			if face == FACE_NEG_Y && entry.Textures["bottom"] != "" {
+				def.Textures[face] = entry.Textures["bottom"]
+			}
+			if entry.Textures[faceNames[face]] != "" {
+				def.Textures[face] = entry.Textures[faceNames[face]]
+			}
+
+			if def.Textures[face] == "" {
+				return nil, fmt.Errorf("block `%s`: no texture for face %s", entry.Name, faceNames[face])
+			}
+		}
+
+		registry.defs[def.Id] = &def
+		registry.byName[def.Name] = &def
+	}
+
+	return &registry, nil
+}
+
+func (self *BlockRegistry) Get(id BlockId) *BlockDef {
+	return self.defs[id]
+}
+
+// Light level emitted by a block, 0 for air and unknown blocks.
+func (self *BlockRegistry) Emission(id BlockId) uint8 {
+	if self == nil {
+		return 0
+	}
+
+	def := self.defs[id]
+	if def == nil {
+		return 0
+	}
+	return def.Light
+}
+
+// Whether a block is a liquid, false for air and unknown blocks.
+func (self *BlockRegistry) IsLiquid(id BlockId) bool {
+	if self == nil {
+		return false
+	}
+
+	def := self.defs[id]
+	return def != nil && def.Liquid
+}
+
+func (self *BlockRegistry) ByName(name string) *BlockDef {
+	return self.byName[name]
+}
+
+// All definitions, sorted by id.
+func (self *BlockRegistry) Defs() []*BlockDef {
+	defs := make([]*BlockDef, 0, len(self.defs))
+	for _, def := range self.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Id < defs[j].Id })
+	return defs
+}
@@ -10,9 +10,24 @@ const (
 type Chunk struct {
 	Width, Height, Depth uint
 	Blocks   []BlockId
+	// sky light in the high nibble, block light in the low nibble
+	Light    []uint8
+	// blocks changed since the chunk was generated or last saved
+	Modified bool
+}
+
+func (self *Chunk) index(x, y, z uint) uint {
+	return x + z*self.Width + y*self.Width*self.Depth
 }
 
 func (self *Chunk) BlockAt(x, y, z uint) BlockId {
-	return self.Blocks[x + z*self.Width + y*self.Width*self.Depth]
+	return self.Blocks[self.index(x, y, z)]
 }
 
+func (self *Chunk) SkyLightAt(x, y, z uint) uint8 {
+	return self.Light[self.index(x, y, z)] >> 4
+}
+
+func (self *Chunk) BlockLightAt(x, y, z uint) uint8 {
+	return self.Light[self.index(x, y, z)] & 0xf
+}
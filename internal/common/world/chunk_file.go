@@ -0,0 +1,99 @@
+package world
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Directory inside a save directory holding the chunks changed by the
+// player. Other chunks are generated again from the seed when loaded.
+const CHUNKS_DIR = "chunks"
+
+// Path of the file a chunk is saved to, by the coordinates of its first
+// block.
+func chunkPath(dir string, key [3]int) string {
+	return filepath.Join(dir, CHUNKS_DIR, fmt.Sprintf("%d_%d_%d.bin", key[0], key[1], key[2]))
+}
+
+// SaveChunks writes the chunks changed since they were generated or last
+// saved into dir.
+func (self *World) SaveChunks(dir string) error {
+	for key, chunk := range self.Chunks {
+		if !chunk.Modified {
+			continue
+		}
+		err := writeChunk(chunkPath(dir, key), chunk)
+		if err != nil {
+			return err
+		}
+		chunk.Modified = false
+	}
+	return nil
+}
+
+// Writes the blocks of a chunk, gzipped; light is computed again on load.
+func writeChunk(path string, chunk *Chunk) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	raw := make([]byte, len(chunk.Blocks))
+	for i, block := range chunk.Blocks {
+		raw[i] = byte(block)
+	}
+	var data bytes.Buffer
+	writer := gzip.NewWriter(&data)
+	_, err = writer.Write(raw)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a broken chunk
+	err = os.WriteFile(path+".tmp", data.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Reads the blocks of a saved chunk of the given size, or nil if the chunk
+// was never saved.
+func readChunk(path string, size uint) ([]BlockId, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read `%s`: %w", path, err)
+	}
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read `%s`: %w", path, err)
+	}
+	if uint(len(raw)) != size {
+		return nil, fmt.Errorf("failed to read `%s`: expected %d blocks, got %d", path, size, len(raw))
+	}
+
+	blocks := make([]BlockId, len(raw))
+	for i, block := range raw {
+		blocks[i] = BlockId(block)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,80 @@
+package world
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestWorld() *World {
+	return &World{Chunks: map[[3]int]*Chunk{}, Seed: 1, Generator: GENERATOR_FLAT}
+}
+
+func TestSaveLoadChangedChunks(t *testing.T) {
+	dir := t.TempDir()
+	saved := newTestWorld()
+	for x := 0; x < 2; x++ {
+		err := saved.LoadChunk(x, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	old, _ := saved.BlockAt(1, 2, 3)
+	block := old + 1
+	err := saved.SetBlock(1, 2, 3, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = saved.Save(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Chunks[[3]int{0, 0, 0}].Modified {
+		t.Error("chunk still modified after saving")
+	}
+	if _, err := os.Stat(chunkPath(dir, [3]int{CHUNK_SIZE, 0, 0})); err == nil {
+		t.Error("unchanged chunk written to the save")
+	}
+
+	loaded := newTestWorld()
+	err = loaded.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = loaded.LoadChunk(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := loaded.BlockAt(1, 2, 3); got != block {
+		t.Errorf("changed block loaded as %d, want %d", got, block)
+	}
+	if got, _ := loaded.BlockAt(0, 0, 0); got != saved.Chunks[[3]int{0, 0, 0}].BlockAt(0, 0, 0) {
+		t.Errorf("unchanged block of a saved chunk loaded as %d", got)
+	}
+}
+
+func TestLoadCorruptChunk(t *testing.T) {
+	dir := t.TempDir()
+	world := newTestWorld()
+	world.Name = "corrupt"
+	err := world.Save(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(dir, CHUNKS_DIR), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(chunkPath(dir, [3]int{}), []byte("not gzip"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = world.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := world.LoadChunk(0, 0, 0); err == nil {
+		t.Fatal("corrupt chunk loaded without error")
+	}
+}
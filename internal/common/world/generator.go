@@ -0,0 +1,92 @@
+package world
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Generator returns the block generated at x, y, z.
+type Generator func(x, y, z int) BlockId
+
+// Names of the terrain generators.
+const (
+	// rolling dirt hills dotted with orium hearts
+	GENERATOR_DEFAULT = "default"
+	// a flat layer of dirt
+	GENERATOR_FLAT = "flat"
+)
+
+var GENERATORS = []string{GENERATOR_DEFAULT, GENERATOR_FLAT}
+
+// Game modes, only stored with the world for now.
+const (
+	GAME_MODE_SURVIVAL = "survival"
+	GAME_MODE_CREATIVE = "creative"
+)
+
+var GAME_MODES = []string{GAME_MODE_SURVIVAL, GAME_MODE_CREATIVE}
+
+// Height of the ground of the flat generator, in blocks.
+const FLAT_HEIGHT = 8
+
+// Cheap deterministic hash of a block column, for scattering features.
+func columnHash(x, z int) uint32 {
+	h := uint32(x)*73856093 ^ uint32(z)*19349663
+	h ^= h >> 13
+	h *= 0x5bd1e995
+	return h ^ h>>15
+}
+
+// NewGenerator returns the terrain generator with the given name, seeded.
+// An empty name is the default generator.
+func NewGenerator(name string, seed int64) (Generator, error) {
+	switch name {
+	case GENERATOR_DEFAULT, "":
+		return defaultGenerator(seed), nil
+	case GENERATOR_FLAT:
+		return flatGenerator, nil
+	}
+	return nil, fmt.Errorf("unknown world generator `%s`", name)
+}
+
+func defaultGenerator(seed int64) Generator {
+	// the seed shifts the hills and scatters the hearts differently; seed 0
+	// gives the original terrain
+	shift := float64(uint64(seed) % 32)
+	salt := uint32(seed) ^ uint32(uint64(seed)>>32)
+
+	return func(x, y, z int) BlockId {
+		height := int(math.Abs(math.Sin((float64(z)+shift)/16.0*math.Pi)) * 16)
+		if y < height {
+			// the odd glowing orium heart on the surface
+			if y == height-1 && (columnHash(x, z)^salt)%97 == 0 {
+				return 4
+			}
+			return 1
+		}
+
+		return 0
+	}
+}
+
+func flatGenerator(x, y, z int) BlockId {
+	if y >= 0 && y < FLAT_HEIGHT {
+		return 1
+	}
+	return 0
+}
+
+// ParseSeed turns the seed typed by a player into a number: integers are
+// used as they are, any other text is hashed.
+func ParseSeed(text string) int64 {
+	text = strings.TrimSpace(text)
+	if seed, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return seed
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	return int64(hash.Sum64())
+}
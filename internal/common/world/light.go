@@ -0,0 +1,225 @@
+package world
+
+// Light levels go from 0 (dark) to MAX_LIGHT, dropping by one for every
+// block travelled. Sky light keeps its full level while going straight down.
+const MAX_LIGHT = 15
+
+type LightChannel int
+
+const (
+	SKY_LIGHT LightChannel = iota
+	BLOCK_LIGHT
+)
+
+// Offsets to the six neighbours of a block, in world.FACE_* order.
+var neighbourOffsets = [6][3]int{
+	{1, 0, 0},
+	{-1, 0, 0},
+	{0, 1, 0},
+	{0, -1, 0},
+	{0, 0, 1},
+	{0, 0, -1},
+}
+
+type lightNode struct {
+	pos   [3]int
+	level uint8 // level before removal, only used when removing light
+}
+
+// LightAt returns the light level of a channel at x, y, z. Blocks in chunks
+// that are not loaded are dark.
+func (self *World) LightAt(channel LightChannel, x, y, z int) uint8 {
+	chunk, index := self.chunkAt(x, y, z)
+	if chunk == nil {
+		return 0
+	}
+
+	if channel == SKY_LIGHT {
+		return chunk.Light[index] >> 4
+	}
+	return chunk.Light[index] & 0xf
+}
+
+func (self *World) setLight(channel LightChannel, x, y, z int, level uint8, track bool) {
+	chunk, index := self.chunkAt(x, y, z)
+	if chunk == nil {
+		return
+	}
+
+	if channel == SKY_LIGHT {
+		chunk.Light[index] = chunk.Light[index]&0x0f | level<<4
+	} else {
+		chunk.Light[index] = chunk.Light[index]&0xf0 | level
+	}
+
+	if track {
+		self.markDirty(x, y, z)
+	}
+}
+
+// Whether light can pass through the block at x, y, z. Unloaded blocks stop
+// light.
+func (self *World) transparentAt(x, y, z int) bool {
+	chunk, index := self.chunkAt(x, y, z)
+	return chunk != nil && chunk.Blocks[index] == 0
+}
+
+// Whether the block at x, y, z is open to the sky from straight above.
+func (self *World) skyAbove(x, y, z int) bool {
+	chunk, _ := self.chunkAt(x, y+1, z)
+	return chunk == nil || self.LightAt(SKY_LIGHT, x, y+1, z) == MAX_LIGHT
+}
+
+// Level a neighbour reached by moving in direction dir gets from level.
+func spreadLevel(channel LightChannel, dir int, level uint8) uint8 {
+	if channel == SKY_LIGHT && dir == FACE_NEG_Y && level == MAX_LIGHT {
+		return MAX_LIGHT
+	}
+	return level - 1
+}
+
+// Flood fills light outwards from the blocks in queue, using their current
+// level.
+func (self *World) propagateLight(channel LightChannel, queue []lightNode, track bool) {
+	for head := 0; head < len(queue); head++ {
+		pos := queue[head].pos
+		level := self.LightAt(channel, pos[0], pos[1], pos[2])
+		if level <= 1 {
+			continue
+		}
+
+		for dir, offset := range neighbourOffsets {
+			n := [3]int{pos[0] + offset[0], pos[1] + offset[1], pos[2] + offset[2]}
+			if !self.transparentAt(n[0], n[1], n[2]) {
+				continue
+			}
+
+			next := spreadLevel(channel, dir, level)
+			if self.LightAt(channel, n[0], n[1], n[2]) < next {
+				self.setLight(channel, n[0], n[1], n[2], next, track)
+				queue = append(queue, lightNode{pos: n})
+			}
+		}
+	}
+}
+
+// Darkens every block that was lit by the blocks in queue (which must already
+// be dark, with their previous level in the node) and returns the blocks at
+// the edge of the darkened area, from which light must be propagated again.
+func (self *World) removeLight(channel LightChannel, queue []lightNode, track bool) []lightNode {
+	relight := make([]lightNode, 0)
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+
+		for dir, offset := range neighbourOffsets {
+			n := [3]int{node.pos[0] + offset[0], node.pos[1] + offset[1], node.pos[2] + offset[2]}
+			current := self.LightAt(channel, n[0], n[1], n[2])
+			if current == 0 {
+				continue
+			}
+
+			if current < node.level || current == spreadLevel(channel, dir, node.level) {
+				// lit by the removed light
+				self.setLight(channel, n[0], n[1], n[2], 0, track)
+				queue = append(queue, lightNode{n, current})
+
+				// emitters keep their own light
+				chunk, index := self.chunkAt(n[0], n[1], n[2])
+				emission := self.Registry.Emission(chunk.Blocks[index])
+				if channel == BLOCK_LIGHT && emission > 0 {
+					self.setLight(channel, n[0], n[1], n[2], emission, track)
+					relight = append(relight, lightNode{pos: n})
+				}
+			} else {
+				// lit by something else, it may light the darkened area back
+				relight = append(relight, lightNode{pos: n})
+			}
+		}
+	}
+
+	return relight
+}
+
+// Updates both light channels after the block at x, y, z changed from old to
+// id.
+func (self *World) relightBlock(x, y, z int, old, id BlockId) {
+	pos := [3]int{x, y, z}
+
+	for _, channel := range [2]LightChannel{SKY_LIGHT, BLOCK_LIGHT} {
+		relight := make([]lightNode, 0)
+
+		level := self.LightAt(channel, x, y, z)
+		if level > 0 && (id != 0 || channel == BLOCK_LIGHT && self.Registry.Emission(old) > 0) {
+			// an opaque block now stops the light, or an emitter went away
+			self.setLight(channel, x, y, z, 0, true)
+			relight = self.removeLight(channel, []lightNode{{pos, level}}, true)
+		}
+
+		if id == 0 {
+			// light flows into the new gap from around it
+			for _, offset := range neighbourOffsets {
+				relight = append(relight, lightNode{pos: [3]int{x + offset[0], y + offset[1], z + offset[2]}})
+			}
+			if channel == SKY_LIGHT && self.skyAbove(x, y, z) {
+				self.setLight(channel, x, y, z, MAX_LIGHT, true)
+				relight = append(relight, lightNode{pos: pos})
+			}
+		}
+
+		if emission := self.Registry.Emission(id); channel == BLOCK_LIGHT && emission > 0 {
+			self.setLight(channel, x, y, z, emission, true)
+			relight = append(relight, lightNode{pos: pos})
+		}
+
+		self.propagateLight(channel, relight, true)
+	}
+}
+
+// InitLighting computes the light of every loaded chunk from scratch: sky
+// light falls from the top of each column of loaded chunks and block light
+// spreads from emitting blocks.
+func (self *World) InitLighting() {
+	// top chunk of every column of chunks
+	tops := make(map[[2]int]int)
+	for key, chunk := range self.Chunks {
+		for i := range chunk.Light {
+			chunk.Light[i] = 0
+		}
+
+		top, ok := tops[[2]int{key[0], key[2]}]
+		if !ok || key[1] > top {
+			tops[[2]int{key[0], key[2]}] = key[1]
+		}
+	}
+
+	sky := make([]lightNode, 0)
+	for column, top := range tops {
+		for dz := 0; dz < CHUNK_SIZE; dz++ {
+			for dx := 0; dx < CHUNK_SIZE; dx++ {
+				x, z := column[0]+dx, column[1]+dz
+				for y := top + CHUNK_SIZE - 1; self.transparentAt(x, y, z); y-- {
+					self.setLight(SKY_LIGHT, x, y, z, MAX_LIGHT, false)
+					sky = append(sky, lightNode{pos: [3]int{x, y, z}})
+				}
+			}
+		}
+	}
+	self.propagateLight(SKY_LIGHT, sky, false)
+
+	emitters := make([]lightNode, 0)
+	for key, chunk := range self.Chunks {
+		for y := 0; y < int(chunk.Height); y++ {
+			for z := 0; z < int(chunk.Depth); z++ {
+				for x := 0; x < int(chunk.Width); x++ {
+					emission := self.Registry.Emission(chunk.BlockAt(uint(x), uint(y), uint(z)))
+					if emission > 0 {
+						pos := [3]int{key[0] + x, key[1] + y, key[2] + z}
+						self.setLight(BLOCK_LIGHT, pos[0], pos[1], pos[2], emission, false)
+						emitters = append(emitters, lightNode{pos: pos})
+					}
+				}
+			}
+		}
+	}
+	self.propagateLight(BLOCK_LIGHT, emitters, false)
+}
@@ -0,0 +1,134 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Save is a world saved in a directory of the saves directory.
+type Save struct {
+	// path of the save directory
+	Dir  string
+	Info WorldInfo
+}
+
+// Name shown for the save, the directory name for worlds saved without one.
+func (self Save) Name() string {
+	if self.Info.Name != "" {
+		return self.Info.Name
+	}
+	return filepath.Base(self.Dir)
+}
+
+// ListSaves returns the worlds saved in the subdirectories of root, most
+// recently played first. Directories without world metadata are skipped; a
+// missing root holds no worlds.
+func ListSaves(root string) ([]Save, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	saves := []Save{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		info, err := ReadWorldInfo(dir)
+		if err != nil {
+			continue
+		}
+		saves = append(saves, Save{dir, info})
+	}
+
+	sort.SliceStable(saves, func(i, j int) bool {
+		if saves[i].Info.LastPlayed != saves[j].Info.LastPlayed {
+			return saves[i].Info.LastPlayed > saves[j].Info.LastPlayed
+		}
+		return saves[i].Name() < saves[j].Name()
+	})
+	return saves, nil
+}
+
+// Directory name for a world name: letters, digits, '-' and '_' are kept,
+// anything else becomes '_'.
+func saveDirName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune('_')
+		}
+	}
+	if builder.Len() == 0 {
+		return "world"
+	}
+	return builder.String()
+}
+
+// CreateSave writes a new world into a directory of root named after it,
+// adding a number to the name if the directory is taken, and returns the
+// directory.
+func CreateSave(root string, info WorldInfo) (string, error) {
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	base := saveDirName(info.Name)
+	dir := filepath.Join(root, base)
+	for i := 2; ; i++ {
+		// Mkdir fails if the directory exists, so two worlds never share one
+		err = os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		dir = filepath.Join(root, base+"_"+strconv.Itoa(i))
+	}
+
+	if info.Created == 0 {
+		info.Created = time.Now().Unix()
+	}
+	err = WriteWorldInfo(dir, info)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// RenameSave changes the name a saved world is shown with. The directory
+// keeps its name.
+func RenameSave(dir, name string) error {
+	info, err := ReadWorldInfo(dir)
+	if err != nil {
+		return err
+	}
+	info.Name = name
+	return WriteWorldInfo(dir, info)
+}
+
+// DeleteSave removes a save directory and everything in it. It refuses to
+// delete directories that don't hold world metadata.
+func DeleteSave(dir string) error {
+	_, err := os.Stat(filepath.Join(dir, WORLD_INFO_FILE))
+	if err != nil {
+		return fmt.Errorf("`%s` is not a world save: %w", dir, err)
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,154 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Length of a full day in ticks: 20 minutes at 60 ticks per second.
+const DAY_LENGTH = 20 * 60 * 60
+
+// Times of day in ticks since midnight.
+const (
+	TIME_MIDNIGHT = 0
+	TIME_SUNRISE  = DAY_LENGTH / 4
+	TIME_NOON     = DAY_LENGTH / 2
+	TIME_SUNSET   = DAY_LENGTH * 3 / 4
+
+	// a new world starts shortly after sunrise
+	TIME_NEW_WORLD = TIME_SUNRISE + DAY_LENGTH/24
+)
+
+// Advances the world by one fixed tick.
+func (self *World) Tick() {
+	self.Time++
+}
+
+// Fraction of the current day, from 0 (midnight) through 0.5 (noon) to 1.
+func (self *World) TimeOfDay() float64 {
+	t := self.Time % DAY_LENGTH
+	if t < 0 {
+		t += DAY_LENGTH
+	}
+	return float64(t) / DAY_LENGTH
+}
+
+// Day number, starting at 0.
+func (self *World) Day() int64 {
+	return self.Time / DAY_LENGTH
+}
+
+// SetTimeOfDay moves the clock forward to the next occurrence of the given
+// time of day, in ticks since midnight, so that time never goes backwards.
+func (self *World) SetTimeOfDay(ticks int64) {
+	ticks %= DAY_LENGTH
+	if ticks < 0 {
+		ticks += DAY_LENGTH
+	}
+
+	time := self.Day()*DAY_LENGTH + ticks
+	if time < self.Time {
+		time += DAY_LENGTH
+	}
+	self.Time = time
+}
+
+// Name of the file holding the world metadata inside a save directory.
+const WORLD_INFO_FILE = "world.json"
+
+// WorldInfo is the metadata of a world saved to disk. Chunks are saved
+// apart, see SaveChunks.
+type WorldInfo struct {
+	Name      string `json:"name"`
+	Seed      int64  `json:"seed"`
+	Generator string `json:"generator"`
+	GameMode  string `json:"gameMode"`
+	Time      int64  `json:"time"`
+	// unix times, in seconds
+	Created    int64 `json:"created"`
+	LastPlayed int64 `json:"lastPlayed"`
+}
+
+func (self *World) Info() WorldInfo {
+	return WorldInfo{
+		Name:      self.Name,
+		Seed:      self.Seed,
+		Generator: self.Generator,
+		GameMode:  self.GameMode,
+		Time:      self.Time,
+		Created:   self.Created,
+	}
+}
+
+func (self *World) ApplyInfo(info WorldInfo) {
+	self.Name = info.Name
+	self.Seed = info.Seed
+	self.Generator = info.Generator
+	self.GameMode = info.GameMode
+	self.Time = info.Time
+	self.Created = info.Created
+}
+
+// Save writes the world metadata and the changed chunks into dir, creating
+// it if needed, and marks the world as played now.
+func (self *World) Save(dir string) error {
+	info := self.Info()
+	info.LastPlayed = time.Now().Unix()
+	err := WriteWorldInfo(dir, info)
+	if err != nil {
+		return err
+	}
+	return self.SaveChunks(dir)
+}
+
+// WriteWorldInfo writes world metadata into dir, creating it if needed.
+func WriteWorldInfo(dir string, info WorldInfo) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a broken save
+	path := filepath.Join(dir, WORLD_INFO_FILE)
+	err = os.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Load reads the world metadata saved in dir, and makes LoadChunk read the
+// chunks saved there.
+func (self *World) Load(dir string) error {
+	info, err := ReadWorldInfo(dir)
+	if err != nil {
+		return err
+	}
+
+	self.ApplyInfo(info)
+	self.dir = dir
+	return nil
+}
+
+// ReadWorldInfo reads the world metadata saved in dir.
+func ReadWorldInfo(dir string) (WorldInfo, error) {
+	data, err := os.ReadFile(filepath.Join(dir, WORLD_INFO_FILE))
+	if err != nil {
+		return WorldInfo{}, err
+	}
+
+	var info WorldInfo
+	err = json.Unmarshal(data, &info)
+	if err != nil {
+		return WorldInfo{}, fmt.Errorf("failed to parse %s: %w", WORLD_INFO_FILE, err)
+	}
+	return info, nil
+}
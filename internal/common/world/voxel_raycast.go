@@ -24,6 +24,9 @@ func frac1(n float64) float64 {
 
 type VoxelRaycast struct {
 	X, Y, Z int
+	// face of the current voxel the ray entered through, one of the FACE_*
+	// constants, or -1 for the voxel the ray started in
+	Face    int
 	step    [3]float64
 	tDelta  [3]float64
 	tMax    [3]float64
@@ -50,6 +53,16 @@ func (self *VoxelRaycast) Init(from, to [3]float64) {
 	self.X = int(math.Floor(from[0]))
 	self.Y = int(math.Floor(from[1]))
 	self.Z = int(math.Floor(from[2]))
+	self.Face = -1
+}
+
+// Face of a voxel a step along an axis enters through: stepping towards +x
+// enters the next voxel through its -x face.
+func entryFace(axis int, step float64) int {
+	if step > 0 {
+		return FACE_NEG_X + 2*axis
+	}
+	return FACE_POS_X + 2*axis
 }
 
 func (self *VoxelRaycast) Step() {
@@ -61,17 +74,21 @@ func (self *VoxelRaycast) Step() {
 		if self.tMax[0] < self.tMax[2] {
 			self.X += int(self.step[0])
 			self.tMax[0] += self.tDelta[0]
+			self.Face = entryFace(0, self.step[0])
 		} else {
 			self.Z += int(self.step[2])
 			self.tMax[2] += self.tDelta[2]
+			self.Face = entryFace(2, self.step[2])
 		}
 	} else {
 		if self.tMax[1] < self.tMax[2] {
 			self.Y += int(self.step[1])
 			self.tMax[1] += self.tDelta[1]
+			self.Face = entryFace(1, self.step[1])
 		} else {
 			self.Z += int(self.step[2])
 			self.tMax[2] += self.tDelta[2]
+			self.Face = entryFace(2, self.step[2])
 		}
 	}
 }
@@ -2,20 +2,10 @@ package world
 
 import (
 	"fmt"
-	"math"
 )
 
-func generate(x, y, z int) BlockId {
-	// if x&1 == y&1 && y&1 == z&1 {
-	if y < int(math.Abs(math.Sin(float64(z)/16.0*math.Pi)) * 16) {
-		return 1
-	}
-
-	return 0
-}
-
 func GenerateChunk(x, y, z int, width, height, depth uint, genFn func(w, h, d int) BlockId) *Chunk {
-	chunk := Chunk{width, height, depth, make([]BlockId, width*height*depth)}
+	chunk := Chunk{Width: width, Height: height, Depth: depth, Blocks: make([]BlockId, width*height*depth), Light: make([]uint8, width*height*depth)}
 
 	for k := uint(0); k < depth; k++ {
 		for j := uint(0); j < height; j++ {
@@ -32,13 +22,55 @@ func GenerateChunk(x, y, z int, width, height, depth uint, genFn func(w, h, d in
 type World struct {
 	Width, Height, Depth uint
 	Chunks               map[[3]int]*Chunk
+	// used for block properties such as light emission, may be nil
+	Registry             *BlockRegistry
+	// ticks since midnight of the first day
+	Time                 int64
+	// name shown in the world list
+	Name                 string
+	// seed and name of the terrain generator, see NewGenerator
+	Seed                 int64
+	Generator            string
+	// one of the GAME_MODE_* constants
+	GameMode             string
+	// unix time the world was created at
+	Created              int64
+
+	// chunks whose blocks or light changed since the last TakeDirtyChunks
+	dirty                map[[3]int]struct{}
+	// save directory set by Load, whose saved chunks replace generated ones
+	dir                  string
 }
 
-func (self *World) LoadChunk(x, y, z int) {
+// LoadChunk loads the chunk at x, y, z, in chunks: from the save directory
+// if it was saved there, or else from the terrain generator.
+func (self *World) LoadChunk(x, y, z int) error {
 	x *= CHUNK_SIZE
 	y *= CHUNK_SIZE
 	z *= CHUNK_SIZE
-	self.Chunks[[3]int{x, y, z}] = GenerateChunk(x, y, z, CHUNK_SIZE, CHUNK_SIZE, CHUNK_SIZE, generate)
+	key := [3]int{x, y, z}
+	if self.dir != "" {
+		const size = CHUNK_SIZE * CHUNK_SIZE * CHUNK_SIZE
+		blocks, err := readChunk(chunkPath(self.dir, key), size)
+		if err != nil {
+			return err
+		}
+		if blocks != nil {
+			self.Chunks[key] = &Chunk{Width: CHUNK_SIZE, Height: CHUNK_SIZE, Depth: CHUNK_SIZE, Blocks: blocks, Light: make([]uint8, size)}
+			return nil
+		}
+	}
+	self.Chunks[key] = GenerateChunk(x, y, z, CHUNK_SIZE, CHUNK_SIZE, CHUNK_SIZE, self.generator())
+	return nil
+}
+
+// Terrain generator of the world, the default one if its name is unknown.
+func (self *World) generator() Generator {
+	generator, err := NewGenerator(self.Generator, self.Seed)
+	if err != nil {
+		generator, _ = NewGenerator(GENERATOR_DEFAULT, self.Seed)
+	}
+	return generator
 }
 
 type ChunkNotLoadedError [3]int
@@ -47,8 +79,21 @@ func (self *ChunkNotLoadedError) Error() string {
 	return fmt.Sprintf("Chunk not loaded: %d %d %d", self[0], self[1], self[2])
 }
 
+// Returns the chunk containing the block at x, y, z and the block's index in
+// it, or nil if the chunk isn't loaded.
+func (self *World) chunkAt(x, y, z int) (*Chunk, uint) {
+	key := [3]int{x&^(CHUNK_SIZE-1), y&^(CHUNK_SIZE-1), z&^(CHUNK_SIZE-1)}
+	chunk, ok := self.Chunks[key]
+	if !ok {
+		return nil, 0
+	}
+
+	return chunk, chunk.index(uint(x-key[0]), uint(y-key[1]), uint(z-key[2]))
+}
+
 func (self *World) BlockAt(x, y, z int) (BlockId, *ChunkNotLoadedError) {
-	cx, cy, cz := x>>CHUNK_SHIFT, y>>CHUNK_SHIFT, z>>CHUNK_SHIFT
+	// chunks are keyed by the coordinates of their first block
+	cx, cy, cz := x&^(CHUNK_SIZE-1), y&^(CHUNK_SIZE-1), z&^(CHUNK_SIZE-1)
 	chunk, ok := self.Chunks[[3]int{cx, cy, cz}]
 
 	if ok {
@@ -58,3 +103,70 @@ func (self *World) BlockAt(x, y, z int) (BlockId, *ChunkNotLoadedError) {
 	return 0, &ChunkNotLoadedError{x, y, z}
 }
 
+// SetBlock replaces the block at x, y, z and updates the light around it,
+// marking every chunk that needs to be remeshed as dirty.
+func (self *World) SetBlock(x, y, z int, id BlockId) error {
+	chunk, index := self.chunkAt(x, y, z)
+	if chunk == nil {
+		return &ChunkNotLoadedError{x, y, z}
+	}
+
+	old := chunk.Blocks[index]
+	if old == id {
+		return nil
+	}
+
+	chunk.Blocks[index] = id
+	chunk.Modified = true
+	self.markDirty(x, y, z)
+	self.relightBlock(x, y, z, old, id)
+
+	return nil
+}
+
+// Marks the chunk containing x, y, z as dirty, along with the neighbouring
+// chunks whose meshes touch that block. Smooth lighting samples the blocks
+// diagonal to each face corner, so a block on a chunk edge or corner also
+// reaches the chunks across that edge or corner: up to 7 neighbours.
+func (self *World) markDirty(x, y, z int) {
+	if self.dirty == nil {
+		self.dirty = make(map[[3]int]struct{})
+	}
+
+	pos := [3]int{x, y, z}
+	key := [3]int{x&^(CHUNK_SIZE-1), y&^(CHUNK_SIZE-1), z&^(CHUNK_SIZE-1)}
+
+	// chunk offsets to consider along each axis, 0 always included
+	offsets := [3][]int{}
+	for axis := 0; axis < 3; axis++ {
+		offsets[axis] = []int{0}
+		local := pos[axis] - key[axis]
+		if local == 0 {
+			offsets[axis] = append(offsets[axis], -CHUNK_SIZE)
+		} else if local == CHUNK_SIZE-1 {
+			offsets[axis] = append(offsets[axis], CHUNK_SIZE)
+		}
+	}
+
+	for _, dx := range offsets[0] {
+		for _, dy := range offsets[1] {
+			for _, dz := range offsets[2] {
+				self.dirty[[3]int{key[0] + dx, key[1] + dy, key[2] + dz}] = struct{}{}
+			}
+		}
+	}
+}
+
+// TakeDirtyChunks returns the keys of the loaded chunks that changed since
+// the last call, and forgets them.
+func (self *World) TakeDirtyChunks() [][3]int {
+	keys := make([][3]int, 0, len(self.dirty))
+	for key := range self.dirty {
+		if _, ok := self.Chunks[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	self.dirty = nil
+
+	return keys
+}
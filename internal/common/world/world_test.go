@@ -0,0 +1,98 @@
+package world
+
+import (
+	"sort"
+	"testing"
+)
+
+// Loads the chunks from 0 to size-1 along each axis, in chunks.
+func loadChunks(t *testing.T, w *World, size int) {
+	t.Helper()
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			for z := 0; z < size; z++ {
+				err := w.LoadChunk(x, y, z)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(keys [][3]int) [][3]int {
+	sort.Slice(keys, func(i, j int) bool {
+		for axis := 0; axis < 3; axis++ {
+			if keys[i][axis] != keys[j][axis] {
+				return keys[i][axis] < keys[j][axis]
+			}
+		}
+		return false
+	})
+	return keys
+}
+
+func TestDirtyChunksAroundBlock(t *testing.T) {
+	const E = CHUNK_SIZE - 1
+	const C = CHUNK_SIZE
+	cases := []struct {
+		name  string
+		block [3]int
+		want  [][3]int
+	}{
+		{"inside", [3]int{C + 5, C + 5, C + 5}, [][3]int{{C, C, C}}},
+		{"face", [3]int{C + E, C + 5, C + 5}, [][3]int{{C, C, C}, {2 * C, C, C}}},
+		{"edge", [3]int{C + E, C + 5, C}, [][3]int{
+			{C, C, 0}, {C, C, C}, {2 * C, C, 0}, {2 * C, C, C},
+		}},
+		{"corner", [3]int{C + E, C + E, C + E}, [][3]int{
+			{C, C, C}, {C, C, 2 * C}, {C, 2 * C, C}, {C, 2 * C, 2 * C},
+			{2 * C, C, C}, {2 * C, C, 2 * C}, {2 * C, 2 * C, C}, {2 * C, 2 * C, 2 * C},
+		}},
+	}
+	for _, c := range cases {
+		w := newTestWorld()
+		loadChunks(t, w, 3)
+		w.markDirty(c.block[0], c.block[1], c.block[2])
+
+		got := sortedKeys(w.TakeDirtyChunks())
+		want := sortedKeys(c.want)
+		if len(got) != len(want) {
+			t.Errorf("%s block %v dirties %v, want %v", c.name, c.block, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s block %v dirties %v, want %v", c.name, c.block, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestSetBlockDirtiesDiagonalChunks(t *testing.T) {
+	w := newTestWorld()
+	loadChunks(t, w, 2)
+	w.InitLighting()
+	w.TakeDirtyChunks()
+
+	// the ambient occlusion of the faces around this corner is sampled from
+	// all eight chunks meeting there
+	const E = CHUNK_SIZE - 1
+	err := w.SetBlock(E, E, E, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirty := map[[3]int]bool{}
+	for _, key := range w.TakeDirtyChunks() {
+		dirty[key] = true
+	}
+	for key := range w.Chunks {
+		if !dirty[key] {
+			t.Errorf("chunk %v not remeshed after changing the block at the corner", key)
+		}
+	}
+	if len(w.TakeDirtyChunks()) != 0 {
+		t.Error("dirty chunks taken twice")
+	}
+}